@@ -0,0 +1,274 @@
+// Package fileio writes files for the htk and textgrid packages, with options shared by all of their writers.
+package fileio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultFileMode is the permission of written files, unless another is set with WithFileMode.
+const DefaultFileMode os.FileMode = 0644
+
+// WriteOption changes how a file is written.
+type WriteOption func(*writeConfig)
+
+// writeConfig holds the settings chosen with write options.
+type writeConfig struct {
+	overwrite  bool
+	createDirs bool
+	atomic     bool
+	mode       os.FileMode
+	dryRun     bool
+}
+
+// newWriteConfig applies write options to the default settings, which refuse to overwrite, create missing directories and write atomically.
+func newWriteConfig(options []WriteOption) writeConfig {
+	config := writeConfig{createDirs: true, atomic: true, mode: DefaultFileMode}
+	for _, option := range options {
+		option(&config)
+	}
+
+	return config
+}
+
+// WithOverwrite replaces files that already exist instead of returning an error.
+func WithOverwrite(overwrite bool) WriteOption {
+	return func(config *writeConfig) {
+		config.overwrite = overwrite
+	}
+}
+
+// WithCreateDirs creates missing parent directories of a file, which is the default. If set to false, a missing directory is an error.
+func WithCreateDirs(createDirs bool) WriteOption {
+	return func(config *writeConfig) {
+		config.createDirs = createDirs
+	}
+}
+
+// WithAtomic writes to a temporary file in the same directory and renames it into place, which is the default.
+// A failed atomic write leaves an existing file untouched. If set to false, the file is written directly,
+// and removed if writing fails, unless it existed before and was being overwritten.
+func WithAtomic(atomic bool) WriteOption {
+	return func(config *writeConfig) {
+		config.atomic = atomic
+	}
+}
+
+// WithFileMode sets the permission of written files. Atomic writes set the mode exactly, while direct writes are subject to the umask.
+func WithFileMode(mode os.FileMode) WriteOption {
+	return func(config *writeConfig) {
+		config.mode = mode
+	}
+}
+
+// WithDryRun checks that a file could be written and generates its contents, without touching the filesystem.
+func WithDryRun(dryRun bool) WriteOption {
+	return func(config *writeConfig) {
+		config.dryRun = dryRun
+	}
+}
+
+// ResolvePath returns the file a writer should write to. Backslashes are replaced with forward slashes.
+// If the path is an existing directory, or has no extension, the file is named fileName inside of it.
+func ResolvePath(path string, fileName string) string {
+	path = strings.ReplaceAll(path, "\\", "/")
+
+	info, err := os.Stat(path)
+	if filepath.Ext(path) == "" || (err == nil && info.IsDir()) {
+		return filepath.Join(path, fileName)
+	}
+
+	return path
+}
+
+// WriteFile writes the output of a write function to a file, following the given write options.
+// Returns an error wrapping fs.ErrExist if the file exists and overwriting is not allowed.
+func WriteFile(path string, write func(io.Writer) error, options ...WriteOption) error {
+	config := newWriteConfig(options)
+
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		return fmt.Errorf("error: cannot write to %s: is a directory", path)
+	case err == nil && !config.overwrite:
+		return fmt.Errorf("error: cannot write to %s: %w", path, fs.ErrExist)
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	directory := filepath.Dir(path)
+	if !config.createDirs {
+		// without creating directories, the directory has to exist already
+		info, err := os.Stat(directory)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("error: cannot write to %s: %s is not a directory", path, directory)
+		}
+	}
+
+	// generate the contents without writing them anywhere
+	if config.dryRun {
+		return write(io.Discard)
+	}
+
+	if config.createDirs {
+		err = os.MkdirAll(directory, os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.atomic {
+		return writeAtomic(path, directory, write, config)
+	}
+
+	return writeDirect(path, write, config)
+}
+
+// linkFile creates a hard link, and is replaced in tests to act like a filesystem without hard links.
+var linkFile = os.Link
+
+// writeAtomic writes to a temporary file next to path, then moves it to path once everything has been written.
+// Unless overwriting, the temporary file is linked to path instead of renamed, which fails if a file was created at path since it was checked.
+// On filesystems without hard links, such as FAT, the temporary file is copied into a new file at path instead.
+func writeAtomic(path string, directory string, write func(io.Writer) error, config writeConfig) (err error) {
+	temporary, err := os.CreateTemp(directory, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	// remove the temporary file if anything goes wrong before the rename
+	defer func() {
+		if err != nil {
+			_ = temporary.Close()
+			_ = os.Remove(temporary.Name())
+		}
+	}()
+
+	err = writeBuffered(temporary, write)
+	if err != nil {
+		return err
+	}
+
+	err = temporary.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = temporary.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(temporary.Name(), config.mode)
+	if err != nil {
+		return err
+	}
+
+	if config.overwrite {
+		return os.Rename(temporary.Name(), path)
+	}
+
+	err = linkFile(temporary.Name(), path)
+	if err != nil && !errors.Is(err, fs.ErrExist) {
+		err = copyExclusive(temporary.Name(), path, config.mode)
+	}
+	if errors.Is(err, fs.ErrExist) {
+		err = fmt.Errorf("error: cannot write to %s: %w", path, fs.ErrExist)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the file is already in place, so a temporary file that cannot be removed does not fail the write
+	_ = os.Remove(temporary.Name())
+	return nil
+}
+
+// copyExclusive copies a file into a new file at path, failing if path already exists. The new file is removed if copying fails.
+func copyExclusive(source string, path string, mode os.FileMode) (err error) {
+	input, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	output, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = output.Close()
+			_ = os.Remove(path)
+		}
+	}()
+
+	_, err = io.Copy(output, input)
+	if err != nil {
+		return err
+	}
+
+	// the mode is set exactly, like the temporary file it was copied from
+	err = output.Chmod(mode)
+	if err != nil {
+		return err
+	}
+
+	err = output.Sync()
+	if err != nil {
+		return err
+	}
+
+	return output.Close()
+}
+
+// writeDirect writes straight to path, removing the file if writing fails and the file was created by this write.
+func writeDirect(path string, write func(io.Writer) error, config writeConfig) (err error) {
+	// fail if the file was created since it was checked, unless it can be overwritten
+	created := true
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, config.mode)
+	if errors.Is(err, fs.ErrExist) && config.overwrite {
+		created = false
+		file, err = os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, config.mode)
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("error: cannot write to %s: %w", path, fs.ErrExist)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = writeBuffered(file, write)
+	closingError := file.Close()
+	if err == nil {
+		err = closingError
+	}
+
+	if err != nil && created {
+		_ = os.Remove(path)
+	}
+
+	return err
+}
+
+// writeBuffered runs a write function through a buffer, flushing it afterwards.
+func writeBuffered(file *os.File, write func(io.Writer) error) error {
+	buffer := bufio.NewWriter(file)
+
+	err := write(buffer)
+	if err != nil {
+		return err
+	}
+
+	return buffer.Flush()
+}
@@ -0,0 +1,272 @@
+package fileio
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeString returns a write function that writes text.
+func writeString(text string) func(io.Writer) error {
+	return func(writer io.Writer) error {
+		_, err := io.WriteString(writer, text)
+		return err
+	}
+}
+
+func TestWritingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "output.lab")
+
+	err := WriteFile(path, writeString("0 1 a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "0 1 a\n" {
+		t.Fatalf("expected %q, got %q", "0 1 a\n", content)
+	}
+
+	// no temporary files should be left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(entries))
+	}
+}
+
+func TestWritingExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.lab")
+
+	err := os.WriteFile(path, []byte("original"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteFile(path, writeString("new"))
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected an error wrapping fs.ErrExist, got %v", err)
+	}
+
+	err = WriteFile(path, writeString("new"), WithOverwrite(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "new" {
+		t.Fatalf("expected %q, got %q", "new", content)
+	}
+}
+
+func TestFailedWritesLeaveFilesUntouched(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "output.lab")
+	failure := errors.New("failed halfway")
+
+	err := os.WriteFile(path, []byte("original"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failing := func(writer io.Writer) error {
+		_, _ = io.WriteString(writer, "partial")
+		return failure
+	}
+
+	err = WriteFile(path, failing, WithOverwrite(true))
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the error of the write function, got %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "original" {
+		t.Fatalf("expected %q, got %q", "original", content)
+	}
+
+	entries, _ := os.ReadDir(directory)
+	if len(entries) != 1 {
+		t.Fatalf("expected the temporary file to be removed, got %d files", len(entries))
+	}
+
+	// direct writes keep a file they were overwriting
+	err = WriteFile(path, failing, WithOverwrite(true), WithAtomic(false))
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the error of the write function, got %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected the overwritten file to be kept, got %v", err)
+	}
+
+	// but remove a partial file they created
+	created := filepath.Join(directory, "created.lab")
+	err = WriteFile(created, failing, WithAtomic(false))
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the error of the write function, got %v", err)
+	}
+	if _, err := os.Stat(created); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected the partial file to be removed, got %v", err)
+	}
+}
+
+func TestWritingFileCreatedSinceChecked(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "output.lab")
+
+	// a file created after WriteFile checked for it, but before the atomic write finished
+	racing := func(writer io.Writer) error {
+		err := os.WriteFile(path, []byte("original"), 0644)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(writer, "new")
+		return err
+	}
+
+	err := WriteFile(path, racing)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected an error wrapping fs.ErrExist, got %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "original" {
+		t.Fatalf("expected %q, got %q", "original", content)
+	}
+
+	entries, _ := os.ReadDir(directory)
+	if len(entries) != 1 {
+		t.Fatalf("expected the temporary file to be removed, got %d files", len(entries))
+	}
+}
+
+func TestWritingFileWithoutHardLinks(t *testing.T) {
+	// filesystems such as FAT cannot create hard links
+	linkFile = func(string, string) error {
+		return &os.LinkError{Op: "link", Err: errors.ErrUnsupported}
+	}
+	defer func() { linkFile = os.Link }()
+
+	directory := t.TempDir()
+	path := filepath.Join(directory, "output.lab")
+
+	err := WriteFile(path, writeString("new"), WithFileMode(0600))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "new" {
+		t.Fatalf("expected %q, got %q", "new", content)
+	}
+	if info, _ := os.Stat(path); runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(directory)
+	if len(entries) != 1 {
+		t.Fatalf("expected the temporary file to be removed, got %d files", len(entries))
+	}
+
+	// a file created since it was checked is still not overwritten
+	racing := func(writer io.Writer) error {
+		err := os.WriteFile(path, []byte("original"), 0644)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(writer, "newer")
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteFile(path, racing)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected an error wrapping fs.ErrExist, got %v", err)
+	}
+
+	content, _ = os.ReadFile(path)
+	if string(content) != "original" {
+		t.Fatalf("expected %q, got %q", "original", content)
+	}
+}
+
+func TestWritingWithoutCreatingDirectories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "output.lab")
+
+	err := WriteFile(path, writeString("0 1 a\n"), WithCreateDirs(false))
+	if err == nil {
+		t.Fatal("expected an error for a missing directory, got nil")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "nested", "output.lab")
+
+	called := false
+	err := WriteFile(path, func(writer io.Writer) error {
+		called = true
+		return writeString("0 1 a\n")(writer)
+	}, WithDryRun(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !called {
+		t.Fatal("expected the write function to be called")
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected nothing to be created, got %v", err)
+	}
+}
+
+func TestFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "output.lab")
+
+	err := WriteFile(path, writeString("0 1 a\n"), WithFileMode(0600))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestResolvingPath(t *testing.T) {
+	directory := t.TempDir()
+
+	if path := ResolvePath(directory, "a.lab"); path != filepath.Join(directory, "a.lab") {
+		t.Fatalf("expected a file inside the directory, got %s", path)
+	}
+
+	if path := ResolvePath("out/b.lab", "a.lab"); path != "out/b.lab" {
+		t.Fatalf("expected %s, got %s", "out/b.lab", path)
+	}
+
+	if path := ResolvePath("out\\labs", "a.lab"); path != filepath.Join("out/labs", "a.lab") {
+		t.Fatalf("expected %s, got %s", filepath.Join("out/labs", "a.lab"), path)
+	}
+}
@@ -4,10 +4,8 @@ go 1.23.0
 
 toolchain go1.24.1
 
-require github.com/gammazero/deque v1.0.0
-
 require (
 	github.com/TomOnTime/utfutil v1.0.0
 	github.com/saintfish/chardet v0.0.0-20230101081208-5e3ef4b5456d
-	golang.org/x/text v0.23.0 // indirect
+	golang.org/x/text v0.23.0
 )
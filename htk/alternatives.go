@@ -0,0 +1,65 @@
+package htk
+
+import "fmt"
+
+// alternativeSeparator is the line that separates alternative transcriptions in an HTK label file.
+const alternativeSeparator = "///"
+
+// GetAlternatives gets every transcription of a Lab. The first transcription is always the annotations of the Lab.
+func (lab *Lab) GetAlternatives() [][]Annotation {
+	return append([][]Annotation{lab.annotations}, lab.alternatives...)
+}
+
+// SetAlternatives sets every transcription of a Lab. The first transcription becomes the annotations of the Lab.
+// Annotations without a sample rate take the sample rate of the Lab.
+func (lab *Lab) SetAlternatives(alternatives [][]Annotation) {
+	lab.ends = nil
+	lab.span = 0
+	if len(alternatives) == 0 {
+		lab.annotations = nil
+		lab.alternatives = nil
+		return
+	}
+
+	lab.annotations = lab.withSampleRate(alternatives[0])
+	lab.alternatives = nil
+	for _, alternative := range alternatives[1:] {
+		lab.alternatives = append(lab.alternatives, lab.withSampleRate(alternative))
+	}
+}
+
+// PushAlternative pushes a single transcription to the end of the alternatives of a Lab.
+// Annotations without a sample rate take the sample rate of the Lab.
+func (lab *Lab) PushAlternative(annotations []Annotation) {
+	lab.alternatives = append(lab.alternatives, lab.withSampleRate(annotations))
+}
+
+// GetAlternativeCount gets the total amount of transcriptions in a Lab, including its annotations.
+func (lab *Lab) GetAlternativeCount() int {
+	return len(lab.alternatives) + 1
+}
+
+// SelectAlternative makes the transcription at the given index the annotations of a Lab.
+// The selected transcription moves to the front, and the others keep their order behind it, so an N-best ranking is not scrambled.
+func (lab *Lab) SelectAlternative(index int) error {
+	if index < 0 || index > len(lab.alternatives) {
+		return fmt.Errorf("error: lab %s has no alternative %d, it has %d alternatives", lab.name, index, lab.GetAlternativeCount())
+	}
+
+	if index == 0 {
+		return nil
+	}
+
+	selected := lab.alternatives[index-1]
+	copy(lab.alternatives[1:index], lab.alternatives[:index-1])
+	lab.alternatives[0] = lab.annotations
+	lab.annotations = selected
+	lab.ends = nil
+
+	return nil
+}
+
+// ClearAlternatives removes every transcription of a Lab except its annotations.
+func (lab *Lab) ClearAlternatives() {
+	lab.alternatives = nil
+}
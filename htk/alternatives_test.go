@@ -0,0 +1,69 @@
+package htk
+
+import "testing"
+
+func TestReadingAlternatives(t *testing.T) {
+	lab, err := ReadLab("examples/nbest.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.GetAlternativeCount() != 3 {
+		t.Fatalf("wanted 3 alternatives, recieved %d", lab.GetAlternativeCount())
+	} else if !isEqualSlice(lab.GetLabels(), []string{"sil", "a", "sil"}) {
+		t.Fatalf("wanted first alternative as annotations, recieved %v", lab.GetLabels())
+	} else if len(lab.GetAlternatives()[2]) != 2 {
+		t.Fatalf("wanted 2 annotations in third alternative, recieved %d", len(lab.GetAlternatives()[2]))
+	}
+}
+
+func TestSelectingAlternative(t *testing.T) {
+	lab, err := ReadLab("examples/nbest.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = lab.SelectAlternative(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isEqualSlice(lab.GetLabels(), []string{"sil", "o", "sil"}) {
+		t.Fatalf("wanted second alternative as annotations, recieved %v", lab.GetLabels())
+	} else if lab.GetAlternatives()[1][1].GetLabel() != "a" {
+		t.Fatalf("wanted previous annotations to take the place of the selected alternative")
+	}
+
+	// the others keep their ranking behind the selected transcription
+	err = lab.SelectAlternative(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alternatives := lab.GetAlternatives()
+	if alternatives[0][1].GetEnd() != 1.5 || alternatives[1][1].GetLabel() != "o" || alternatives[2][1].GetEnd() != 1.2 {
+		t.Fatalf("wanted the last alternative followed by o and a, recieved %v", alternatives)
+	}
+
+	err = lab.SelectAlternative(3)
+	if err == nil {
+		t.Fatal("wanted error when selecting an alternative that does not exist")
+	}
+}
+
+func TestWritingAlternatives(t *testing.T) {
+	lab, err := ReadLab("examples/nbest.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "0.00 0.50 sil -120.5\n0.50 1.20 a -80.25\n1.20 1.50 sil -40\n///\n0.00 0.40 sil -130\n0.40 1.20 o -95.5\n1.20 1.50 sil -38\n///\n0.00 0.50 sil\n0.50 1.50 a\n"
+	if lab.ToString() != expected {
+		t.Fatalf("malformed lab string with alternatives, recieved:\n%s", lab.ToString())
+	}
+
+	lab.ClearAlternatives()
+	if lab.GetAlternativeCount() != 1 {
+		t.Fatalf("wanted 1 alternative after clearing, recieved %d", lab.GetAlternativeCount())
+	}
+}
@@ -1,10 +1,28 @@
 package htk
 
 // Annotation structs contain a starting and ending time in seconds, with a text label.
+// Annotations can also carry the optional score, auxiliary labels and comment defined by the HTK label format,
+// and the frequency range of an Audacity spectral label.
 type Annotation struct {
-	start float64
-	end   float64
-	label string
+	start         float64
+	end           float64
+	label         string
+	score         float64
+	hasScore      bool
+	auxiliaries   []Auxiliary
+	comment       string
+	line          int
+	lowFrequency  float64
+	highFrequency float64
+	hasFrequency  bool
+	sampleRate    int
+}
+
+// Auxiliary structs are the auxiliary labels that can follow the main label of an HTK Annotation, with an optional score.
+type Auxiliary struct {
+	name     string
+	score    float64
+	hasScore bool
 }
 
 // GetDuration gets the total duration of an Annotation.
@@ -41,3 +59,112 @@ func (annotation *Annotation) GetLabel() string {
 func (annotation *Annotation) SetLabel(label string) {
 	annotation.label = label
 }
+
+// GetLine gets the line number an Annotation was read from. Returns 0 if the Annotation was not read from a file.
+func (annotation *Annotation) GetLine() int {
+	return annotation.line
+}
+
+// GetFrequencyRange gets the low and high frequency in Hz of an Annotation, such as the spectral selection of an Audacity label.
+// Returns 0 for both if the Annotation has no frequency range.
+func (annotation *Annotation) GetFrequencyRange() (float64, float64) {
+	return annotation.lowFrequency, annotation.highFrequency
+}
+
+// SetFrequencyRange sets the low and high frequency in Hz of an Annotation.
+func (annotation *Annotation) SetFrequencyRange(low float64, high float64) {
+	annotation.lowFrequency = low
+	annotation.highFrequency = high
+	annotation.hasFrequency = true
+}
+
+// HasFrequencyRange returns true if the Annotation has a frequency range.
+func (annotation *Annotation) HasFrequencyRange() bool {
+	return annotation.hasFrequency
+}
+
+// ClearFrequencyRange removes the frequency range of an Annotation.
+func (annotation *Annotation) ClearFrequencyRange() {
+	annotation.lowFrequency = 0
+	annotation.highFrequency = 0
+	annotation.hasFrequency = false
+}
+
+// GetScore gets the score of an Annotation. Returns 0 if the Annotation has no score.
+func (annotation *Annotation) GetScore() float64 {
+	return annotation.score
+}
+
+// SetScore sets the score of an Annotation.
+func (annotation *Annotation) SetScore(score float64) {
+	annotation.score = score
+	annotation.hasScore = true
+}
+
+// HasScore returns true if the Annotation has a score.
+func (annotation *Annotation) HasScore() bool {
+	return annotation.hasScore
+}
+
+// ClearScore removes the score of an Annotation.
+func (annotation *Annotation) ClearScore() {
+	annotation.score = 0
+	annotation.hasScore = false
+}
+
+// GetAuxiliaries gets the auxiliary labels of an Annotation.
+func (annotation *Annotation) GetAuxiliaries() []Auxiliary {
+	return annotation.auxiliaries
+}
+
+// SetAuxiliaries sets the auxiliary labels of an Annotation.
+func (annotation *Annotation) SetAuxiliaries(auxiliaries []Auxiliary) {
+	annotation.auxiliaries = auxiliaries
+}
+
+// PushAuxiliary pushes a single Auxiliary into the auxiliary labels of an Annotation.
+func (annotation *Annotation) PushAuxiliary(auxiliary Auxiliary) {
+	annotation.auxiliaries = append(annotation.auxiliaries, auxiliary)
+}
+
+// GetComment gets the comment of an Annotation.
+func (annotation *Annotation) GetComment() string {
+	return annotation.comment
+}
+
+// SetComment sets the comment of an Annotation.
+func (annotation *Annotation) SetComment(comment string) {
+	annotation.comment = comment
+}
+
+// GetName gets the name of an Auxiliary.
+func (auxiliary *Auxiliary) GetName() string {
+	return auxiliary.name
+}
+
+// SetName sets the name of an Auxiliary.
+func (auxiliary *Auxiliary) SetName(name string) {
+	auxiliary.name = name
+}
+
+// GetScore gets the score of an Auxiliary. Returns 0 if the Auxiliary has no score.
+func (auxiliary *Auxiliary) GetScore() float64 {
+	return auxiliary.score
+}
+
+// SetScore sets the score of an Auxiliary.
+func (auxiliary *Auxiliary) SetScore(score float64) {
+	auxiliary.score = score
+	auxiliary.hasScore = true
+}
+
+// HasScore returns true if the Auxiliary has a score.
+func (auxiliary *Auxiliary) HasScore() bool {
+	return auxiliary.hasScore
+}
+
+// ClearScore removes the score of an Auxiliary.
+func (auxiliary *Auxiliary) ClearScore() {
+	auxiliary.score = 0
+	auxiliary.hasScore = false
+}
@@ -3,7 +3,7 @@ package htk
 import "testing"
 
 func TestCreatingAnnotation(t *testing.T) {
-	annotation := Annotation{0.0, 10.0, "test"}
+	annotation := Annotation{start: 0.0, end: 10.0, label: "test"}
 
 	if annotation.GetStart() != 0.0 {
 		t.Errorf("expected 0.0, got %f", annotation.GetStart())
@@ -32,3 +32,34 @@ func TestCreatingAnnotation(t *testing.T) {
 		t.Errorf("expected test2, got %s", annotation.GetLabel())
 	}
 }
+
+func TestAnnotationScoresAndAuxiliaries(t *testing.T) {
+	annotation := Annotation{start: 0.0, end: 10.0, label: "test"}
+
+	if annotation.HasScore() {
+		t.Errorf("expected no score, got %f", annotation.GetScore())
+	}
+
+	annotation.SetScore(-12.5)
+	annotation.PushAuxiliary(Auxiliary{name: "word"})
+	annotation.SetComment("comment")
+
+	if !annotation.HasScore() || annotation.GetScore() != -12.5 {
+		t.Errorf("expected score -12.5, got %f", annotation.GetScore())
+	}
+	if len(annotation.GetAuxiliaries()) != 1 || annotation.GetAuxiliaries()[0].GetName() != "word" {
+		t.Errorf("expected auxiliary \"word\", got %v", annotation.GetAuxiliaries())
+	}
+	if annotation.GetAuxiliaries()[0].HasScore() {
+		t.Errorf("expected auxiliary without score")
+	}
+	if annotation.GetComment() != "comment" {
+		t.Errorf("expected comment \"comment\", got %q", annotation.GetComment())
+	}
+
+	annotation.ClearScore()
+
+	if annotation.HasScore() {
+		t.Errorf("expected score to be cleared")
+	}
+}
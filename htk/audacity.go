@@ -0,0 +1,156 @@
+package htk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vocatart/golab/fileio"
+)
+
+// audacitySpectralPrefix starts the line holding the frequency range of the preceding Audacity label.
+const audacitySpectralPrefix = "\\"
+
+// ReadAudacity takes a path to an Audacity label track export and reads its contents into a Lab. See ParseAudacity for the accepted format.
+func ReadAudacity(path string) (Lab, error) {
+	labelData, err := os.Open(path)
+	if err != nil {
+		return Lab{}, err
+	}
+	defer func() {
+		closingError := labelData.Close()
+		if err == nil {
+			err = closingError
+		}
+	}()
+
+	return ParseAudacity(labelData, filepath.Base(path))
+}
+
+// ParseAudacity reads an Audacity label track from an io.Reader into a Lab with the given name.
+// Each line holds a tab separated start time, end time and label, so labels may contain spaces.
+// A following line of the form `\<tab>low<tab>high` holds the frequency range of a spectral label, which is kept on the Annotation.
+func ParseAudacity(reader io.Reader, name string) (Lab, error) {
+	lab := Lab{name: name, precision: 6}
+	parsedPrecision := false
+
+	line := bufio.NewScanner(reader)
+	lineNumber := 0
+
+	for line.Scan() {
+		lineNumber++
+		text := strings.TrimRight(line.Text(), "\r")
+
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+
+		// spectral selection lines belong to the label before them
+		if fields[0] == audacitySpectralPrefix {
+			if len(lab.annotations) == 0 || len(fields) < 3 {
+				return lab, fmt.Errorf("error: malformed audacity labels %s at line %d: unexpected frequency range", name, lineNumber)
+			}
+
+			low, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+			if err != nil {
+				return lab, fmt.Errorf("error: malformed audacity labels %s at line %d: %v", name, lineNumber, err)
+			}
+			high, err := strconv.ParseFloat(strings.TrimSpace(fields[2]), 64)
+			if err != nil {
+				return lab, fmt.Errorf("error: malformed audacity labels %s at line %d: %v", name, lineNumber, err)
+			}
+
+			lab.annotations[len(lab.annotations)-1].SetFrequencyRange(low, high)
+			continue
+		}
+
+		if len(fields) < 2 {
+			return lab, fmt.Errorf("error: malformed audacity labels %s at line %d: expected start and end times", name, lineNumber)
+		}
+
+		start, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		if err != nil {
+			return lab, fmt.Errorf("error: malformed audacity labels %s at line %d: %v", name, lineNumber, err)
+		}
+		end, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			return lab, fmt.Errorf("error: malformed audacity labels %s at line %d: %v", name, lineNumber, err)
+		}
+
+		// parse the precision if it hasn't been parsed yet
+		if !parsedPrecision {
+			lab.parsePrecision(strings.TrimSpace(fields[1]))
+			parsedPrecision = true
+		}
+
+		// the label is everything after the second tab, including any further tabs
+		label := ""
+		if len(fields) > 2 {
+			label = strings.Join(fields[2:], "\t")
+		}
+
+		lab.annotations = append(lab.annotations, Annotation{start: start, end: end, label: label, line: lineNumber})
+	}
+	if err := line.Err(); err != nil {
+		return lab, err
+	}
+
+	return lab, nil
+}
+
+// WriteAudacity writes a Lab to a file from a given path as an Audacity label track, which Audacity can import with File > Import > Labels.
+// If the file already exists, it will be overwritten only if overwrite is set to true.
+func (lab *Lab) WriteAudacity(path string, overwrite ...bool) error {
+	// if no overwrite is specified, default to false
+	if len(overwrite) == 0 {
+		overwrite = append(overwrite, false)
+	}
+
+	return lab.WriteAudacityWith(path, fileio.WithOverwrite(overwrite[0]))
+}
+
+// WriteAudacityWith writes a Lab to a file as an Audacity label track, following the given write options.
+func (lab *Lab) WriteAudacityWith(path string, options ...fileio.WriteOption) error {
+	return fileio.WriteFile(path, func(writer io.Writer) error {
+		_, err := lab.WriteAudacityTo(writer)
+		return err
+	}, options...)
+}
+
+// WriteAudacityTo writes a Lab to an io.Writer as an Audacity label track, using the precision of the Lab.
+// Times are always written in seconds, and annotations with a frequency range are followed by a spectral selection line.
+// Returns the number of bytes written.
+func (lab *Lab) WriteAudacityTo(writer io.Writer) (int64, error) {
+	var written int64
+
+	for _, annotation := range lab.annotations {
+		count, err := fmt.Fprintf(writer, "%s\t%s\t%s\n", lab.formatDecimal(annotation.start), lab.formatDecimal(annotation.end), annotation.label)
+		written += int64(count)
+		if err != nil {
+			return written, err
+		}
+
+		if !annotation.hasFrequency {
+			continue
+		}
+
+		count, err = fmt.Fprintf(writer, "%s\t%s\t%s\n", audacitySpectralPrefix, lab.formatDecimal(annotation.lowFrequency), lab.formatDecimal(annotation.highFrequency))
+		written += int64(count)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// formatDecimal converts a number into a decimal string with the precision of a Lab, regardless of its TimeUnit.
+func (lab *Lab) formatDecimal(value float64) string {
+	return strconv.FormatFloat(value, 'f', int(lab.precision), 64)
+}
@@ -0,0 +1,66 @@
+package htk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadingAudacity(t *testing.T) {
+	lab, err := ReadAudacity("examples/audacity/labels.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.GetLength() != 3 {
+		t.Fatalf("wanted 3 annotations, recieved %d", lab.GetLength())
+	} else if lab.GetAnnotations()[0].GetLabel() != "first label" {
+		t.Fatalf("wanted label with spaces, recieved %q", lab.GetAnnotations()[0].GetLabel())
+	} else if lab.GetPrecision() != 6 {
+		t.Fatalf("wanted precision 6, recieved %d", lab.GetPrecision())
+	}
+
+	spectral := lab.GetAnnotations()[1]
+	low, high := spectral.GetFrequencyRange()
+	if !spectral.HasFrequencyRange() || low != 100 || high != 2000.5 {
+		t.Fatalf("wanted frequency range [100, 2000.5], recieved [%f, %f]", low, high)
+	}
+
+	if lab.GetAnnotations()[0].HasFrequencyRange() {
+		t.Fatal("wanted no frequency range on regular label")
+	}
+
+	_, err = ParseAudacity(strings.NewReader("\\\t100\t200\n"), "malformed")
+	if err == nil {
+		t.Fatal("wanted error for frequency range without label")
+	}
+}
+
+func TestWritingAudacity(t *testing.T) {
+	lab, err := ReadAudacity("examples/audacity/labels.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var builder strings.Builder
+	_, err = lab.WriteAudacityTo(&builder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "0.000000\t1.250000\tfirst label\n1.250000\t2.500000\tspectral\n\\\t100.000000\t2000.500000\n2.500000\t2.500000\t\n"
+	if builder.String() != expected {
+		t.Fatalf("wanted %q, recieved %q", expected, builder.String())
+	}
+
+	err = lab.WriteAudacity("examples/audacity/output.txt", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := ReadAudacity("examples/audacity/output.txt")
+	if err != nil {
+		t.Fatal(err)
+	} else if written.GetLength() != lab.GetLength() {
+		t.Fatal("audacity labels did not round-trip")
+	}
+}
@@ -0,0 +1,136 @@
+package htk
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// globCharacters are the characters that make a corpus a glob pattern rather than a path.
+const globCharacters = "*?[\\"
+
+// CorpusResult is the outcome of reading a single file of a corpus. Err is set if the file could not be read.
+type CorpusResult struct {
+	Path string
+	Lab  Lab
+	Err  error
+}
+
+// LoadCorpus reads every Lab of a corpus concurrently with a bounded number of workers, which defaults to the number of CPUs.
+// The corpus is either a directory, which is searched recursively for .lab files, or a glob pattern such as "labs/*/*.lab".
+// Results are sorted by path, and a file or subdirectory that cannot be read only sets the Err of its CorpusResult.
+// An error is returned if the corpus is neither an existing path nor a glob pattern.
+// If the context is cancelled, files that were not read yet get the error of the context, which is also returned.
+func LoadCorpus(ctx context.Context, corpus string, workers int, options ...ReadOption) ([]CorpusResult, error) {
+	results, err := corpusPaths(corpus)
+	if err != nil {
+		return nil, err
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan int)
+
+	var group sync.WaitGroup
+	for range min(workers, len(results)) {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+
+			for index := range jobs {
+				// the context may have been cancelled while this job was waiting
+				if ctx.Err() != nil {
+					results[index].Err = ctx.Err()
+					continue
+				}
+
+				results[index].Lab, results[index].Err = ReadLab(results[index].Path, options...)
+			}
+		}()
+	}
+
+	sent := 0
+send:
+	for ; sent < len(results); sent++ {
+		// paths that could not be walked already have their error
+		if results[sent].Err != nil {
+			continue
+		}
+
+		select {
+		case jobs <- sent:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	group.Wait()
+
+	// files that were never sent to a worker
+	for index := sent; index < len(results); index++ {
+		if results[index].Err == nil {
+			results[index].Err = ctx.Err()
+		}
+	}
+
+	return results, ctx.Err()
+}
+
+// corpusPaths returns a CorpusResult for every .lab file in a directory and its subdirectories, or every path matching a glob pattern, sorted by path.
+// Entries that cannot be walked get a CorpusResult with their error, so the rest of the corpus is still read.
+func corpusPaths(corpus string) ([]CorpusResult, error) {
+	var results []CorpusResult
+
+	info, err := os.Stat(corpus)
+	if err != nil || !info.IsDir() {
+		// a path that does not exist is only searched for if it is a pattern
+		if err != nil && !strings.ContainsAny(corpus, globCharacters) {
+			return nil, err
+		}
+
+		paths, err := filepath.Glob(corpus)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(paths)
+		for _, path := range paths {
+			results = append(results, CorpusResult{Path: path})
+		}
+
+		return results, nil
+	}
+
+	err = filepath.WalkDir(corpus, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			results = append(results, CorpusResult{Path: path, Err: err})
+
+			// the contents of a directory that cannot be read are skipped
+			if entry != nil && entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".lab") {
+			results = append(results, CorpusResult{Path: path})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+	return results, nil
+}
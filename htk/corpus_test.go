@@ -0,0 +1,129 @@
+package htk
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCorpus(t *testing.T) {
+	results, err := LoadCorpus(context.Background(), "examples/corpus", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		filepath.Join("examples", "corpus", "a.lab"),
+		filepath.Join("examples", "corpus", "b.lab"),
+		filepath.Join("examples", "corpus", "nested", "c.lab"),
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("wanted %d results, recieved %d", len(expected), len(results))
+	}
+
+	for i, result := range results {
+		if result.Path != expected[i] {
+			t.Fatalf("wanted path %s, recieved %s", expected[i], result.Path)
+		}
+	}
+
+	if results[0].Err != nil || results[0].Lab.GetLength() != 2 {
+		t.Fatalf("wanted 2 annotations without error, recieved %d and %v", results[0].Lab.GetLength(), results[0].Err)
+	}
+	if results[1].Err == nil {
+		t.Fatal("wanted error for malformed lab")
+	}
+	if results[2].Err != nil || results[2].Lab.GetLabels()[0] != "c" {
+		t.Fatalf("wanted nested lab to be read, recieved %v", results[2].Err)
+	}
+}
+
+func TestLoadCorpusGlob(t *testing.T) {
+	results, err := LoadCorpus(context.Background(), "examples/sync/*/*.lab", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 5 {
+		t.Fatalf("wanted 5 results, recieved %d", len(results))
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	if results[0].Path != filepath.Join("examples", "sync", "full", "01.lab") {
+		t.Fatalf("wanted results sorted by path, recieved %s first", results[0].Path)
+	}
+}
+
+func TestLoadCorpusCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := LoadCorpus(ctx, "examples/corpus", 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("wanted context cancelled error, recieved %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("wanted 3 results, recieved %d", len(results))
+	}
+	for _, result := range results {
+		if result.Path == "" || !errors.Is(result.Err, context.Canceled) {
+			t.Fatalf("wanted cancelled result with path, recieved %q and %v", result.Path, result.Err)
+		}
+	}
+}
+
+func TestLoadCorpusMissing(t *testing.T) {
+	_, err := LoadCorpus(context.Background(), "examples/missing_corpus", 2)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("wanted error for missing corpus, recieved %v", err)
+	}
+
+	// a pattern matching nothing is an empty corpus
+	results, err := LoadCorpus(context.Background(), "examples/missing_corpus/*.lab", 2)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("wanted no results without error, recieved %d and %v", len(results), err)
+	}
+}
+
+func TestLoadCorpusUnreadableDirectory(t *testing.T) {
+	corpus := t.TempDir()
+	for _, path := range []string{"a.lab", filepath.Join("locked", "b.lab")} {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(corpus, path)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(filepath.Join(corpus, path), []byte("0 1 a\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	locked := filepath.Join(corpus, "locked")
+	err := os.Chmod(locked, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	if _, err := os.ReadDir(locked); err == nil {
+		t.Skip("directory permissions are not enforced for this user")
+	}
+
+	results, err := LoadCorpus(context.Background(), corpus, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 || results[0].Err != nil || results[1].Path != locked || results[1].Err == nil {
+		t.Fatalf("wanted a.lab to be read and an error for the locked directory, recieved %v", results)
+	}
+}
@@ -0,0 +1,183 @@
+package htk
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Shift moves every Annotation of a Lab, including alternative transcriptions, by an offset in seconds.
+func (lab *Lab) Shift(offset float64) {
+	lab.forEachAnnotation(func(annotation *Annotation) {
+		annotation.start += offset
+		annotation.end += offset
+	})
+
+	// the end of the chunk moves with the annotations
+	if lab.span > 0 {
+		lab.span = math.Max(0, lab.span+offset)
+	}
+}
+
+// Scale multiplies the times of every Annotation of a Lab, including alternative transcriptions, by a factor.
+// This is useful after resampling or time-stretching audio. The factor must be positive.
+func (lab *Lab) Scale(factor float64) error {
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return fmt.Errorf("error: cannot scale lab %s by factor %s, it must be positive", lab.name, f2s(factor))
+	}
+
+	lab.forEachAnnotation(func(annotation *Annotation) {
+		annotation.start *= factor
+		annotation.end *= factor
+	})
+	lab.span *= factor
+
+	return nil
+}
+
+// Crop removes every Annotation of a Lab, including alternative transcriptions, that lies outside of the range from start to end.
+// Annotations that straddle start or end are clipped to the range, and so is the span of the Lab. Times are kept as they are, use Shift to move the range to 0.
+func (lab *Lab) Crop(start float64, end float64) error {
+	if end < start {
+		return fmt.Errorf("error: cannot crop lab %s to end %s before start %s", lab.name, f2s(end), f2s(start))
+	}
+
+	lab.annotations = cropAnnotations(lab.annotations, start, end)
+	lab.ends = nil
+	if lab.span > end {
+		lab.span = end
+	}
+	for i, alternative := range lab.alternatives {
+		lab.alternatives[i] = cropAnnotations(alternative, start, end)
+	}
+
+	return nil
+}
+
+// SplitAt cuts a Lab into multiple labs at the given times, leaving the original Lab unchanged.
+// Each resulting Lab is cropped to its part and shifted to start at 0, so it lines up with the matching chunk of audio.
+// Each part keeps the length of its chunk, including any gap after its last Annotation, which Concatenate uses to join the parts again.
+// The first part starts at 0 (or the first start time if it is negative) and the last part ends at the end of the last Annotation.
+// Resulting labs are named after the original Lab with their part number appended, such as "utterance_1.lab".
+func (lab *Lab) SplitAt(times ...float64) ([]Lab, error) {
+	splitTimes := append([]float64{}, times...)
+	sort.Float64s(splitTimes)
+
+	// the range of the whole lab
+	start, end := 0.0, 0.0
+	if len(lab.annotations) > 0 {
+		start = math.Min(0, lab.annotations[0].start)
+		end = lab.annotations[len(lab.annotations)-1].end
+	}
+	for _, annotation := range lab.annotations {
+		end = math.Max(end, annotation.end)
+	}
+
+	for _, time := range splitTimes {
+		if time < start || time > end {
+			return nil, fmt.Errorf("error: cannot split lab %s at %s, outside of its range [%s, %s]", lab.name, f2s(time), f2s(start), f2s(end))
+		}
+	}
+
+	boundaries := append(append([]float64{start}, splitTimes...), end)
+	extension := filepath.Ext(lab.name)
+	baseName := strings.TrimSuffix(lab.name, extension)
+
+	var parts []Lab
+	for i := 0; i < len(boundaries)-1; i++ {
+		part := lab.copy()
+		part.name = fmt.Sprintf("%s_%d%s", baseName, i+1, extension)
+
+		err := part.Crop(boundaries[i], boundaries[i+1])
+		if err != nil {
+			return nil, err
+		}
+		part.Shift(-boundaries[i])
+		part.span = boundaries[i+1] - boundaries[i]
+
+		parts = append(parts, part)
+	}
+
+	return parts, nil
+}
+
+// Concatenate joins multiple labs into one, shifting each Lab to start where the previous one ended.
+// Each Lab is expected to start at 0, such as the parts created by SplitAt.
+// A part created by SplitAt ends where its chunk ended, so joining the parts of a split Lab gives back the original times.
+// Any other Lab ends with its last Annotation, or its span if it is later, see GetSpan.
+// The resulting Lab takes its name, precision, TimeUnit, sample rate and Encoding from the first Lab. Alternative transcriptions are not joined.
+func Concatenate(labs ...Lab) Lab {
+	result := Lab{}
+	if len(labs) == 0 {
+		return result
+	}
+
+	result.name = labs[0].name
+	result.precision = labs[0].precision
+	result.unit = labs[0].unit
+	result.sampleRate = labs[0].sampleRate
+	result.encoding = labs[0].encoding
+
+	offset := 0.0
+	for _, lab := range labs {
+		end := lab.span
+		for _, annotation := range lab.annotations {
+			end = math.Max(end, annotation.end)
+
+			annotation.start += offset
+			annotation.end += offset
+			result.annotations = append(result.annotations, annotation)
+		}
+
+		offset += end
+	}
+	result.span = offset
+
+	return result
+}
+
+// forEachAnnotation calls a function on every Annotation of a Lab, including alternative transcriptions.
+func (lab *Lab) forEachAnnotation(apply func(annotation *Annotation)) {
+	for i := range lab.annotations {
+		apply(&lab.annotations[i])
+	}
+	lab.ends = nil
+
+	for _, alternative := range lab.alternatives {
+		for i := range alternative {
+			apply(&alternative[i])
+		}
+	}
+}
+
+// copy returns a Lab with its own copy of the annotations and alternative transcriptions, so it can be changed without affecting the original.
+func (lab *Lab) copy() Lab {
+	result := *lab
+	result.annotations = append([]Annotation(nil), lab.annotations...)
+
+	result.alternatives = nil
+	for _, alternative := range lab.alternatives {
+		result.alternatives = append(result.alternatives, append([]Annotation(nil), alternative...))
+	}
+
+	return result
+}
+
+// cropAnnotations returns the annotations that overlap the range from start to end, clipped to the range.
+func cropAnnotations(annotations []Annotation, start float64, end float64) []Annotation {
+	var result []Annotation
+
+	for _, annotation := range annotations {
+		if annotation.end <= start || annotation.start >= end {
+			continue
+		}
+
+		annotation.start = math.Max(annotation.start, start)
+		annotation.end = math.Min(annotation.end, end)
+		result = append(result, annotation)
+	}
+
+	return result
+}
@@ -0,0 +1,160 @@
+package htk
+
+import "testing"
+
+// newEditLab returns a small Lab used by the editing tests.
+func newEditLab() Lab {
+	lab := Lab{name: "edit.lab", precision: 2}
+	lab.SetAnnotations([]Annotation{
+		{start: 0, end: 1, label: "a"},
+		{start: 1, end: 2, label: "b"},
+		{start: 2, end: 4, label: "c"},
+	})
+
+	return lab
+}
+
+func TestShiftingLab(t *testing.T) {
+	lab := newEditLab()
+	lab.PushAlternative([]Annotation{{start: 0, end: 4, label: "abc"}})
+	lab.Shift(0.5)
+
+	if lab.ToString() != "0.50 1.50 a\n1.50 2.50 b\n2.50 4.50 c\n///\n0.50 4.50 abc\n" {
+		t.Fatalf("malformed shifted lab, recieved %q", lab.ToString())
+	}
+}
+
+func TestScalingLab(t *testing.T) {
+	lab := newEditLab()
+
+	err := lab.Scale(0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.ToString() != "0.00 0.50 a\n0.50 1.00 b\n1.00 2.00 c\n" {
+		t.Fatalf("malformed scaled lab, recieved %q", lab.ToString())
+	}
+
+	if lab.Scale(0) == nil {
+		t.Fatal("wanted error when scaling by 0")
+	}
+}
+
+func TestCroppingLab(t *testing.T) {
+	lab := newEditLab()
+
+	err := lab.Crop(0.5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.ToString() != "0.50 1.00 a\n1.00 2.00 b\n2.00 3.00 c\n" {
+		t.Fatalf("malformed cropped lab, recieved %q", lab.ToString())
+	}
+
+	if lab.Crop(2, 1) == nil {
+		t.Fatal("wanted error when cropping with end before start")
+	}
+}
+
+func TestSplittingLab(t *testing.T) {
+	lab := newEditLab()
+
+	parts, err := lab.SplitAt(2.5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"0.00 1.00 a\n",
+		"0.00 1.00 b\n1.00 1.50 c\n",
+		"0.00 1.50 c\n",
+	}
+
+	if len(parts) != len(expected) {
+		t.Fatalf("wanted %d parts, recieved %d", len(expected), len(parts))
+	}
+	for i, part := range parts {
+		if part.ToString() != expected[i] {
+			t.Errorf("wanted part %d %q, recieved %q", i+1, expected[i], part.ToString())
+		}
+	}
+
+	if parts[1].GetName() != "edit_2.lab" {
+		t.Fatalf("wanted name edit_2.lab, recieved %s", parts[1].GetName())
+	}
+	if lab.GetLength() != 3 {
+		t.Fatal("wanted original lab to be unchanged")
+	}
+
+	_, err = lab.SplitAt(5)
+	if err == nil {
+		t.Fatal("wanted error when splitting outside of the lab")
+	}
+}
+
+func TestConcatenatingLabs(t *testing.T) {
+	lab := newEditLab()
+
+	parts, err := lab.SplitAt(1, 2.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined := Concatenate(parts...)
+	if joined.ToString() != "0.00 1.00 a\n1.00 2.00 b\n2.00 2.50 c\n2.50 4.00 c\n" {
+		t.Fatalf("malformed concatenated lab, recieved %q", joined.ToString())
+	}
+	if joined.GetName() != "edit_1.lab" || joined.GetPrecision() != 2 {
+		t.Fatalf("wanted name and precision of first lab, recieved %s and %d", joined.GetName(), joined.GetPrecision())
+	}
+}
+
+func TestConcatenatingLabWithGaps(t *testing.T) {
+	lab := Lab{name: "gaps.lab", precision: 2}
+	lab.SetAnnotations([]Annotation{
+		{start: 0, end: 1, label: "a"},
+		{start: 2, end: 4, label: "c"},
+	})
+
+	parts, err := lab.SplitAt(1.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined := Concatenate(parts...)
+	if joined.ToString() != lab.ToString() {
+		t.Fatalf("wanted %q after splitting and concatenating, recieved %q", lab.ToString(), joined.ToString())
+	}
+}
+
+func TestConcatenatingCroppedParts(t *testing.T) {
+	lab := newEditLab()
+
+	parts, err := lab.SplitAt(2.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first part now ends at 1.5, so the second part follows from there
+	err = parts[0].Crop(0, 1.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts[0].GetSpan() != 1.5 {
+		t.Fatalf("wanted span 1.5 after cropping, recieved %v", parts[0].GetSpan())
+	}
+
+	joined := Concatenate(parts...)
+	if joined.ToString() != "0.00 1.00 a\n1.00 1.50 b\n1.50 3.00 c\n" {
+		t.Fatalf("malformed concatenated lab, recieved %q", joined.ToString())
+	}
+
+	// a part with new annotations ends with its last annotation
+	parts[0].SetAnnotations([]Annotation{{start: 0, end: 0.5, label: "a"}})
+	joined = Concatenate(parts...)
+	if joined.ToString() != "0.00 0.50 a\n0.50 2.00 c\n" {
+		t.Fatalf("malformed concatenated lab, recieved %q", joined.ToString())
+	}
+}
@@ -0,0 +1,295 @@
+package htk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/TomOnTime/utfutil"
+	"github.com/saintfish/chardet"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encoding is the character encoding of a label file.
+// Labs remember the Encoding they were read in, and are written in it again unless it is changed with SetEncoding.
+type Encoding uint8
+
+const (
+	// UTF8 is UTF-8 without a byte order mark, which is the default.
+	UTF8 Encoding = iota
+	// UTF8BOM is UTF-8 starting with a byte order mark, as written by some Windows editors.
+	UTF8BOM
+	// UTF16LE is little endian UTF-16, written with a byte order mark.
+	UTF16LE
+	// UTF16BE is big endian UTF-16, written with a byte order mark.
+	UTF16BE
+	// ShiftJIS is the Shift-JIS encoding common in Japanese singing voice datasets.
+	ShiftJIS
+	// EUCJP is the EUC-JP encoding.
+	EUCJP
+	// Latin1 is ISO-8859-1, in which every byte is a character. Text in other single byte encodings is read as Latin1.
+	Latin1
+	// Windows1252 is the Windows code page 1252, which adds characters such as € to Latin1.
+	Windows1252
+)
+
+// utf8ByteOrderMark starts files encoded as UTF8BOM.
+var utf8ByteOrderMark = []byte{0xEF, 0xBB, 0xBF}
+
+// String returns the name of an Encoding.
+func (enc Encoding) String() string {
+	switch enc {
+	case UTF8:
+		return "UTF-8"
+	case UTF8BOM:
+		return "UTF-8 BOM"
+	case UTF16LE:
+		return "UTF-16LE"
+	case UTF16BE:
+		return "UTF-16BE"
+	case ShiftJIS:
+		return "Shift_JIS"
+	case EUCJP:
+		return "EUC-JP"
+	case Latin1:
+		return "ISO-8859-1"
+	case Windows1252:
+		return "windows-1252"
+	default:
+		return fmt.Sprintf("Encoding(%d)", uint8(enc))
+	}
+}
+
+// WithEncoding reads a file in the given Encoding instead of detecting it.
+func WithEncoding(enc Encoding) ReadOption {
+	return func(config *readConfig) {
+		config.encoding = enc
+		config.encodingSet = true
+	}
+}
+
+// GetEncoding gets the Encoding a Lab was read in, which is also the Encoding it is written in.
+func (lab *Lab) GetEncoding() Encoding {
+	return lab.encoding
+}
+
+// SetEncoding sets the Encoding a Lab is written in.
+func (lab *Lab) SetEncoding(enc Encoding) {
+	lab.encoding = enc
+}
+
+// DetectEncoding guesses the Encoding of the contents of a file.
+// Byte order marks are checked first, then valid UTF-8, then the guesses of a character set detector.
+// Short Japanese files are often guessed wrong, so Shift-JIS and EUC-JP are also tried when the detector finds a Japanese character set possible.
+// Either way, text is only read as Japanese if it is valid in the Encoding and, for Shift-JIS, has characters that Latin-1 letters cannot make, see hasShiftJISPairs.
+// Any other text is read as Windows1252 when it is guessed to be windows-1252, and as Latin1 otherwise, so every byte is kept.
+func DetectEncoding(data []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(data, utf8ByteOrderMark):
+		return UTF8BOM
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return UTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return UTF16BE
+	case utf8.Valid(data):
+		return UTF8
+	}
+
+	// text too short for the detector to guess is read as Latin1
+	results, err := chardet.NewTextDetector().DetectAll(data)
+	if err != nil || len(results) == 0 {
+		return Latin1
+	}
+
+	switch best := results[0].Charset; {
+	case best == "Shift_JIS" && isShiftJIS(data) && hasShiftJISPairs(data):
+		return ShiftJIS
+	case best == "EUC-JP" && isEUCJP(data):
+		return EUCJP
+	case best == "UTF-16LE":
+		return UTF16LE
+	case best == "UTF-16BE":
+		return UTF16BE
+	}
+
+	japanese := false
+	for _, result := range results {
+		japanese = japanese || result.Language == "ja"
+	}
+
+	switch {
+	case japanese && isEUCJP(data):
+		return EUCJP
+	case japanese && isShiftJIS(data) && hasShiftJISPairs(data):
+		return ShiftJIS
+	case results[0].Charset == "windows-1252" && isWindows1252(data):
+		return Windows1252
+	default:
+		return Latin1
+	}
+}
+
+// hasShiftJISPairs returns true if a double byte Shift-JIS character in data has a lead byte from 0x81 to 0x9F, or a trail byte from 0x80.
+// Latin-1 text is only valid Shift-JIS when each accented letter, which is read as a lead byte from 0xE0, is followed by an ASCII letter as its trail byte.
+// Japanese text has kana and common kanji with lead bytes below 0xA0, and most rarer kanji have trail bytes from 0x80.
+func hasShiftJISPairs(data []byte) bool {
+	for i := 0; i < len(data); i++ {
+		current := data[i]
+		if current < 0x80 || (current >= 0xA1 && current <= 0xDF) {
+			continue
+		}
+
+		if current <= 0x9F || (i+1 < len(data) && data[i+1] >= 0x80) {
+			return true
+		}
+		i++
+	}
+
+	return false
+}
+
+// isWindows1252 returns true if data has none of the bytes left undefined by Windows code page 1252.
+func isWindows1252(data []byte) bool {
+	for _, current := range data {
+		switch current {
+		case 0x81, 0x8D, 0x8F, 0x90, 0x9D:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isShiftJIS returns true if data is a valid sequence of Shift-JIS characters.
+func isShiftJIS(data []byte) bool {
+	for i := 0; i < len(data); i++ {
+		current := data[i]
+
+		switch {
+		// ASCII and half-width katakana are single bytes
+		case current < 0x80 || (current >= 0xA1 && current <= 0xDF):
+			continue
+		case (current >= 0x81 && current <= 0x9F) || (current >= 0xE0 && current <= 0xFC):
+			if i+1 >= len(data) {
+				return false
+			}
+
+			trail := data[i+1]
+			if trail < 0x40 || trail == 0x7F || trail > 0xFC {
+				return false
+			}
+			i++
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isEUCJP returns true if data is a valid sequence of EUC-JP characters.
+func isEUCJP(data []byte) bool {
+	for i := 0; i < len(data); i++ {
+		current := data[i]
+
+		switch {
+		case current < 0x80:
+			continue
+		// half-width katakana and JIS X 0208 characters are two bytes
+		case current == 0x8E || (current >= 0xA1 && current <= 0xFE):
+			if i+1 >= len(data) || data[i+1] < 0xA1 || data[i+1] > 0xFE {
+				return false
+			}
+			i++
+		// JIS X 0212 characters are three bytes
+		case current == 0x8F:
+			if i+2 >= len(data) || data[i+1] < 0xA1 || data[i+1] > 0xFE || data[i+2] < 0xA1 || data[i+2] > 0xFE {
+				return false
+			}
+			i += 2
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// decodeContent reads all of an io.Reader and converts it into UTF-8, detecting its Encoding unless one is set with WithEncoding.
+func decodeContent(reader io.Reader, config readConfig) (io.Reader, Encoding, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, UTF8, err
+	}
+
+	enc := config.encoding
+	if !config.encodingSet {
+		enc = DetectEncoding(data)
+	}
+
+	switch enc {
+	case UTF8, UTF8BOM:
+		// a byte order mark is skipped if there is one
+		return utfutil.BytesReader(data, utfutil.UTF8), enc, nil
+	case UTF16LE:
+		return utfutil.BytesReader(data, utfutil.UTF16LE), enc, nil
+	case UTF16BE:
+		return utfutil.BytesReader(data, utfutil.UTF16BE), enc, nil
+	}
+
+	if enc.encoding() == nil {
+		return nil, enc, fmt.Errorf("error: unknown encoding %s", enc)
+	}
+
+	decoded, err := enc.encoding().NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, enc, fmt.Errorf("error: cannot decode %s: %v", enc, err)
+	}
+
+	return bytes.NewReader(decoded), enc, nil
+}
+
+// encode converts UTF-8 text into an Encoding, adding a byte order mark where the Encoding has one.
+func (enc Encoding) encode(text string) ([]byte, error) {
+	switch enc {
+	case UTF8:
+		return []byte(text), nil
+	case UTF8BOM:
+		return append(append([]byte(nil), utf8ByteOrderMark...), text...), nil
+	}
+
+	if enc.encoding() == nil {
+		return nil, fmt.Errorf("error: unknown encoding %s", enc)
+	}
+
+	encoded, err := enc.encoding().NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("error: cannot encode text as %s: %v", enc, err)
+	}
+
+	return encoded, nil
+}
+
+// encoding returns the x/text encoding of an Encoding, or nil for UTF-8 and unknown encodings.
+func (enc Encoding) encoding() encoding.Encoding {
+	switch enc {
+	case UTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case UTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case ShiftJIS:
+		return japanese.ShiftJIS
+	case EUCJP:
+		return japanese.EUCJP
+	case Latin1:
+		return charmap.ISO8859_1
+	case Windows1252:
+		return charmap.Windows1252
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,201 @@
+package htk
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestReadingEncodings(t *testing.T) {
+	tests := []struct {
+		path     string
+		encoding Encoding
+	}{
+		{"examples/encoding/shift_jis.lab", ShiftJIS},
+		{"examples/encoding/shift_jis_kanji.lab", ShiftJIS},
+		{"examples/encoding/utf8_bom.lab", UTF8BOM},
+		{"examples/encoding/utf16.lab", UTF16LE},
+		{"examples/short.lab", UTF8},
+	}
+
+	for _, test := range tests {
+		lab, err := ReadLab(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if lab.GetEncoding() != test.encoding {
+			t.Errorf("wanted encoding %s for %s, recieved %s", test.encoding, test.path, lab.GetEncoding())
+		}
+	}
+
+	lab, err := ReadLab("examples/encoding/shift_jis.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.GetLabels()[1] != "あ" || lab.GetLabels()[10] != "わ" {
+		t.Fatalf("wanted labels あ and わ, recieved %s and %s", lab.GetLabels()[1], lab.GetLabels()[10])
+	}
+
+	// rarer kanji without any kana are still shift-jis
+	kanji, err := ReadLab("examples/encoding/shift_jis_kanji.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEqualSlice(kanji.GetLabels(), []string{"薔薇", "齟齬", "蹂躙", "躊躇"}) {
+		t.Fatalf("wanted labels 薔薇 齟齬 蹂躙 躊躇, recieved %v", kanji.GetLabels())
+	}
+
+	bom, err := ReadLab("examples/encoding/utf8_bom.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEqualSlice(bom.GetLabels(), lab.GetLabels()) {
+		t.Fatalf("wanted labels %v, recieved %v", lab.GetLabels(), bom.GetLabels())
+	}
+}
+
+func TestReadingWithEncoding(t *testing.T) {
+	lab, err := ReadLab("examples/encoding/shift_jis.lab", WithEncoding(ShiftJIS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lab.GetLabels()[2] != "か" {
+		t.Fatalf("wanted label か, recieved %s", lab.GetLabels()[2])
+	}
+
+	// reading shift-jis as euc-jp gives different labels
+	lab, err = ReadLab("examples/encoding/shift_jis.lab", WithEncoding(EUCJP))
+	if err == nil && lab.GetLabels()[2] == "か" {
+		t.Fatal("wanted label to differ when read in the wrong encoding")
+	}
+
+	_, err = ReadLab("examples/encoding/shift_jis.lab", WithEncoding(Encoding(100)))
+	if err == nil {
+		t.Fatal("wanted error for unknown encoding")
+	}
+}
+
+func TestWritingEncodings(t *testing.T) {
+	lab, err := ReadLab("examples/encoding/shift_jis.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// labs are written in the encoding they were read in
+	err = lab.WriteLab("examples/encoding/output_shift_jis.lab", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original, err := os.ReadFile("examples/encoding/shift_jis.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile("examples/encoding/output_shift_jis.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(original, written) {
+		t.Fatal("wanted shift-jis output to match the original file")
+	}
+
+	lab.SetEncoding(UTF8BOM)
+	var buffer bytes.Buffer
+	_, err = lab.WriteTo(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buffer.Bytes(), utf8ByteOrderMark) {
+		t.Fatal("wanted output to start with a byte order mark")
+	}
+
+	lab.SetEncoding(UTF16BE)
+	buffer.Reset()
+	_, err = lab.WriteTo(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ParseLab(&buffer, "utf16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.GetEncoding() != UTF16BE || !isEqualSlice(result.GetLabels(), lab.GetLabels()) {
+		t.Fatalf("wanted utf-16be labels %v, recieved %s labels %v", lab.GetLabels(), result.GetEncoding(), result.GetLabels())
+	}
+
+	// characters missing from shift-jis cannot be written
+	lab.SetEncoding(ShiftJIS)
+	lab.PushAnnotation(Annotation{start: 2.1, end: 2.2, label: "😀"})
+	_, err = lab.WriteTo(&buffer)
+	if err == nil {
+		t.Fatal("wanted error for character missing from shift-jis")
+	}
+}
+
+func TestDetectingEncoding(t *testing.T) {
+	eucjp, err := ShiftJIS.encode("")
+	if err != nil || len(eucjp) != 0 {
+		t.Fatalf("wanted empty output, recieved %v and %v", eucjp, err)
+	}
+
+	eucjp, err = EUCJP.encode("0 1 あ\n1 2 い\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	detected := DetectEncoding(eucjp)
+	if detected != EUCJP && detected != ShiftJIS {
+		t.Fatalf("wanted a japanese encoding, recieved %s", detected)
+	}
+
+	if !isEUCJP(eucjp) {
+		t.Fatal("wanted euc-jp bytes to be valid euc-jp")
+	}
+
+	detected = DetectEncoding([]byte{0x80, 0x80, 0x80})
+	if detected != Latin1 {
+		t.Fatalf("wanted unknown bytes to be read as latin-1, recieved %s", detected)
+	}
+}
+
+func TestReadingLatin1(t *testing.T) {
+	// valid shift-jis, but not guessed as a japanese encoding
+	content := []byte("0 1 \xe9t\xe9s\n1 2 caf\xe9s\n")
+	if !isShiftJIS(content) {
+		t.Fatal("wanted latin-1 text that is also valid shift-jis")
+	}
+
+	lab, err := ParseLab(bytes.NewReader(content), "latin1.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lab.GetEncoding() != Latin1 || lab.GetLabels()[0] != "étés" || lab.GetLabels()[1] != "cafés" {
+		t.Fatalf("wanted latin-1 labels étés and cafés, recieved %v in %s", lab.GetLabels(), lab.GetEncoding())
+	}
+
+	var buffer bytes.Buffer
+	_, err = lab.WriteTo(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buffer.Bytes(), []byte("caf\xe9s")) {
+		t.Fatalf("wanted lab to be written in latin-1, recieved %q", buffer.String())
+	}
+
+	// guessed as shift-jis, but made of latin-1 letters
+	lab, err = ParseLab(bytes.NewReader([]byte("0 1 \xe9t\xe9\n")), "short.lab")
+	if err != nil || lab.GetLabels()[0] != "été" {
+		t.Fatalf("wanted latin-1 label été, recieved %v and %v", lab.GetLabels(), err)
+	}
+
+	lab, err = ParseLab(bytes.NewReader([]byte("0 1 \x80uro\n1 2 caf\xe9\n2 3 na\xefve\n")), "cp1252.lab", WithEncoding(Windows1252))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lab.GetLabels()[0] != "€uro" {
+		t.Fatalf("wanted windows-1252 label €uro, recieved %s", lab.GetLabels()[0])
+	}
+}
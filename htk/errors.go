@@ -0,0 +1,75 @@
+package htk
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// ParseError describes a problem found while reading a label file, and where it was found.
+// Line and Column start at 1, and are 0 if the problem is not tied to a position, such as a missing sample rate.
+// Field names the part of the line that could not be read, such as "start time" or "label name".
+// Use errors.As to retrieve a ParseError from the error returned by ReadLab or ParseLab.
+type ParseError struct {
+	Path   string
+	Line   int
+	Column int
+	Field  string
+	Cause  error
+}
+
+// Error returns a readable description of a ParseError, starting with its position as path:line:column.
+func (parseError *ParseError) Error() string {
+	location := parseError.Path
+	if parseError.Line > 0 {
+		location += fmt.Sprintf(":%d", parseError.Line)
+		if parseError.Column > 0 {
+			location += fmt.Sprintf(":%d", parseError.Column)
+		}
+	}
+
+	if parseError.Field == "" {
+		return fmt.Sprintf("error: malformed label file %s: %v", location, parseError.Cause)
+	}
+
+	return fmt.Sprintf("error: malformed %s in %s: %v", parseError.Field, location, parseError.Cause)
+}
+
+// Unwrap returns the cause of a ParseError.
+func (parseError *ParseError) Unwrap() error {
+	return parseError.Cause
+}
+
+// splitFields splits a line by whitespace like strings.Fields, also returning the column each field starts at, counted in characters from 1.
+func splitFields(line string) ([]string, []int) {
+	var fields []string
+	var columns []int
+
+	column := 0
+	start := -1
+	startColumn := 0
+
+	for index, character := range line {
+		column++
+
+		if unicode.IsSpace(character) {
+			if start != -1 {
+				fields = append(fields, line[start:index])
+				columns = append(columns, startColumn)
+				start = -1
+			}
+			continue
+		}
+
+		if start == -1 {
+			start = index
+			startColumn = column
+		}
+	}
+
+	if start != -1 {
+		fields = append(fields, line[start:])
+		columns = append(columns, startColumn)
+	}
+
+	return fields, columns
+}
@@ -0,0 +1,79 @@
+package htk
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	_, err := ParseLab(strings.NewReader("0 1 a\n\n  1.0   2.0\n"), "missing.lab")
+	if err == nil {
+		t.Fatal("wanted error for missing label name")
+	}
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("wanted ParseError, recieved %T", err)
+	}
+
+	if parseError.Path != "missing.lab" || parseError.Line != 3 || parseError.Column != 12 || parseError.Field != "label name" {
+		t.Fatalf("wanted missing.lab:3:12 label name, recieved %s:%d:%d %s", parseError.Path, parseError.Line, parseError.Column, parseError.Field)
+	}
+
+	if !strings.HasPrefix(err.Error(), "error: malformed label name in missing.lab:3:12: ") {
+		t.Fatalf("malformed error string, recieved %q", err.Error())
+	}
+}
+
+func TestParseErrorColumns(t *testing.T) {
+	_, err := ParseLab(strings.NewReader("0 1 a\n1 2 ; comment\n"), "comment.lab")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("wanted ParseError, recieved %v", err)
+	}
+	if parseError.Line != 2 || parseError.Column != 5 {
+		t.Fatalf("wanted line 2, column 5, recieved line %d, column %d", parseError.Line, parseError.Column)
+	}
+
+	fields, columns := splitFields("\tあ  b c")
+	if !isEqualSlice(fields, []string{"あ", "b", "c"}) || columns[0] != 2 || columns[1] != 5 || columns[2] != 7 {
+		t.Fatalf("wanted columns [2 5 7], recieved %v", columns)
+	}
+}
+
+func TestParseErrorFromFile(t *testing.T) {
+	_, err := ReadLab("examples/corpus/b.lab")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("wanted ParseError, recieved %v", err)
+	}
+	if parseError.Path != "examples/corpus/b.lab" || parseError.Line != 1 {
+		t.Fatalf("wanted examples/corpus/b.lab:1, recieved %s:%d", parseError.Path, parseError.Line)
+	}
+
+	_, err = ParseLab(strings.NewReader("0 1 a\n"), "samples", WithTimeUnit(Samples))
+	if !errors.As(err, &parseError) || parseError.Field != "time unit" || parseError.Line != 0 {
+		t.Fatalf("wanted time unit ParseError without line, recieved %v", err)
+	}
+
+	_, err = ParseMLF(strings.NewReader("#!MLF!#\n\"a.lab\"\n0 1\n.\n"), "test.mlf")
+	if !errors.As(err, &parseError) || parseError.Line != 3 || parseError.Path != "test.mlf" {
+		t.Fatalf("wanted ParseError at test.mlf:3, recieved %v", err)
+	}
+}
+
+func TestParseErrorUnwrap(t *testing.T) {
+	cause := strconv.ErrSyntax
+	err := error(&ParseError{Path: "a.lab", Line: 1, Column: 1, Field: "score", Cause: cause})
+
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatal("wanted ParseError to unwrap to its cause")
+	}
+	if err.Error() != "error: malformed score in a.lab:1:1: invalid syntax" {
+		t.Fatalf("malformed error string, recieved %q", err.Error())
+	}
+}
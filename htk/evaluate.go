@@ -0,0 +1,250 @@
+package htk
+
+import (
+	"math"
+	"sort"
+)
+
+// defaultTolerances are the tolerances in seconds used by Evaluate when none are given.
+var defaultTolerances = []float64{0.01, 0.02, 0.05}
+
+// toleranceEpsilon absorbs floating point noise when comparing an error to a tolerance, so an error of exactly 20ms is within 20ms.
+const toleranceEpsilon = 1e-9
+
+// BoundaryKind is whether a BoundaryError is measured at the start or the end of an Annotation.
+type BoundaryKind uint8
+
+const (
+	// StartBoundary is the start time of an Annotation.
+	StartBoundary BoundaryKind = iota
+	// EndBoundary is the end time of an Annotation.
+	EndBoundary
+)
+
+// BoundaryError is the difference between a boundary of a reference Annotation and the same boundary of the matching predicted Annotation.
+// Error is the predicted time minus the reference time, so a positive Error means the predicted boundary is late.
+type BoundaryError struct {
+	Label          string
+	Kind           BoundaryKind
+	ReferenceIndex int
+	PredictedIndex int
+	Reference      float64
+	Predicted      float64
+	Error          float64
+}
+
+// ToleranceRate is the percentage of boundaries with an absolute error less than or equal to a tolerance in seconds.
+type ToleranceRate struct {
+	Tolerance float64
+	Percent   float64
+}
+
+// BoundarySummary holds the statistics of a set of boundary errors, in seconds.
+type BoundarySummary struct {
+	Count  int
+	Mean   float64
+	Median float64
+	Within []ToleranceRate
+}
+
+// Evaluation is the result of comparing a predicted Lab to a reference Lab.
+// Labels are aligned by edit distance, and only annotations with identical labels are compared as boundaries.
+// Labels holds a BoundarySummary for each reference label.
+type Evaluation struct {
+	Boundaries    []BoundaryError
+	Summary       BoundarySummary
+	Labels        map[string]BoundarySummary
+	Matches       int
+	Substitutions int
+	Insertions    int
+	Deletions     int
+}
+
+// Evaluate compares the boundaries of a predicted Lab, such as the output of a forced aligner, to a reference Lab.
+// A boundary shared by two matched annotations that follow each other without a gap in both labs is counted once, as the EndBoundary of the first.
+// Tolerances are in seconds, and default to 10, 20 and 50 milliseconds.
+func Evaluate(reference Lab, predicted Lab, tolerances ...float64) Evaluation {
+	if len(tolerances) == 0 {
+		tolerances = defaultTolerances
+	}
+
+	evaluation := Evaluation{Labels: make(map[string]BoundarySummary)}
+	pairs := alignLabels(reference.GetLabels(), predicted.GetLabels())
+
+	perLabel := make(map[string][]float64)
+	var all []float64
+
+	// the previous pair that matched, to find boundaries shared with it
+	previous := labelPair{reference: -1, predicted: -1}
+
+	for _, pair := range pairs {
+		switch {
+		case pair.reference == -1:
+			evaluation.Insertions++
+			continue
+		case pair.predicted == -1:
+			evaluation.Deletions++
+			continue
+		}
+
+		referenceAnnotation := reference.annotations[pair.reference]
+		predictedAnnotation := predicted.annotations[pair.predicted]
+
+		if referenceAnnotation.label != predictedAnnotation.label {
+			evaluation.Substitutions++
+			continue
+		}
+		evaluation.Matches++
+
+		kinds := []BoundaryKind{StartBoundary, EndBoundary}
+		if previous.reference >= 0 && previous.reference == pair.reference-1 && previous.predicted == pair.predicted-1 &&
+			reference.annotations[previous.reference].end == referenceAnnotation.start &&
+			predicted.annotations[previous.predicted].end == predictedAnnotation.start {
+			// the start was already counted as the end of the previous annotation
+			kinds = kinds[1:]
+		}
+		previous = pair
+
+		for _, kind := range kinds {
+			boundary := BoundaryError{
+				Label:          referenceAnnotation.label,
+				Kind:           kind,
+				ReferenceIndex: pair.reference,
+				PredictedIndex: pair.predicted,
+				Reference:      referenceAnnotation.start,
+				Predicted:      predictedAnnotation.start,
+			}
+			if kind == EndBoundary {
+				boundary.Reference = referenceAnnotation.end
+				boundary.Predicted = predictedAnnotation.end
+			}
+			boundary.Error = boundary.Predicted - boundary.Reference
+
+			evaluation.Boundaries = append(evaluation.Boundaries, boundary)
+			all = append(all, math.Abs(boundary.Error))
+			perLabel[boundary.Label] = append(perLabel[boundary.Label], math.Abs(boundary.Error))
+		}
+	}
+
+	evaluation.Summary = summarizeErrors(all, tolerances)
+	for label, errors := range perLabel {
+		evaluation.Labels[label] = summarizeErrors(errors, tolerances)
+	}
+
+	return evaluation
+}
+
+// labelPair is a single step of an alignment between two label sequences. An index of -1 means the label has no counterpart.
+type labelPair struct {
+	reference int
+	predicted int
+}
+
+// alignLabels aligns two label sequences with the lowest number of substitutions, insertions and deletions.
+func alignLabels(reference []string, predicted []string) []labelPair {
+	// distances[i][j] is the edit distance between the first i reference labels and the first j predicted labels
+	distances := make([][]int, len(reference)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(predicted)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+
+	for i := 1; i <= len(reference); i++ {
+		for j := 1; j <= len(predicted); j++ {
+			substitution := distances[i-1][j-1]
+			if reference[i-1] != predicted[j-1] {
+				substitution++
+			}
+
+			distances[i][j] = min(substitution, distances[i-1][j]+1, distances[i][j-1]+1)
+		}
+	}
+
+	// walk back through the table, preferring matches and substitutions
+	var pairs []labelPair
+	i, j := len(reference), len(predicted)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && distances[i][j] == distances[i-1][j-1]+boolToInt(reference[i-1] != predicted[j-1]):
+			pairs = append(pairs, labelPair{reference: i - 1, predicted: j - 1})
+			i--
+			j--
+		case i > 0 && distances[i][j] == distances[i-1][j]+1:
+			pairs = append(pairs, labelPair{reference: i - 1, predicted: -1})
+			i--
+		default:
+			pairs = append(pairs, labelPair{reference: -1, predicted: j - 1})
+			j--
+		}
+	}
+
+	// the pairs were collected from the end
+	for left, right := 0, len(pairs)-1; left < right; left, right = left+1, right-1 {
+		pairs[left], pairs[right] = pairs[right], pairs[left]
+	}
+
+	return pairs
+}
+
+// summarizeErrors calculates the mean, median and tolerance rates of a set of absolute errors.
+func summarizeErrors(errors []float64, tolerances []float64) BoundarySummary {
+	summary := BoundarySummary{Count: len(errors)}
+
+	for _, tolerance := range tolerances {
+		rate := ToleranceRate{Tolerance: tolerance}
+
+		if len(errors) > 0 {
+			within := 0
+			for _, err := range errors {
+				if err <= tolerance+toleranceEpsilon {
+					within++
+				}
+			}
+			rate.Percent = 100 * float64(within) / float64(len(errors))
+		}
+
+		summary.Within = append(summary.Within, rate)
+	}
+
+	if len(errors) == 0 {
+		return summary
+	}
+
+	total := 0.0
+	for _, err := range errors {
+		total += err
+	}
+	summary.Mean = total / float64(len(errors))
+	summary.Median = median(errors)
+
+	return summary
+}
+
+// median returns the median of a slice of values, without reordering it. Returns 0 for an empty slice.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
+
+// boolToInt converts true into 1 and false into 0.
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,65 @@
+package htk
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEvaluatingAlignment(t *testing.T) {
+	reference, err := ParseLab(strings.NewReader("0.00 0.50 sil\n0.50 0.60 k\n0.60 0.90 a\n0.90 1.20 sil\n"), "reference.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	predicted, err := ParseLab(strings.NewReader("0.00 0.52 sil\n0.52 0.63 k\n0.63 0.80 a\n0.80 0.90 N\n0.90 1.20 sil\n"), "predicted.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evaluation := Evaluate(reference, predicted)
+
+	if evaluation.Matches != 4 || evaluation.Insertions != 1 || evaluation.Deletions != 0 || evaluation.Substitutions != 0 {
+		t.Fatalf("wanted 4 matches and 1 insertion, recieved %+v", evaluation)
+	}
+	// boundaries in between matched neighbours are counted once
+	if len(evaluation.Boundaries) != 6 {
+		t.Fatalf("wanted 6 boundaries, recieved %d", len(evaluation.Boundaries))
+	}
+
+	// the end of "a" is 0.1 seconds early
+	end := evaluation.Boundaries[3]
+	if end.Label != "a" || end.Kind != EndBoundary || math.Abs(end.Error+0.1) > 1e-9 {
+		t.Fatalf("wanted end of a to be 0.1 seconds early, recieved %+v", end)
+	}
+
+	// absolute errors are 0, 0.02, 0.03, 0.1, 0, 0
+	if math.Abs(evaluation.Summary.Mean-0.025) > 1e-9 {
+		t.Fatalf("wanted mean 0.025, recieved %f", evaluation.Summary.Mean)
+	}
+	if math.Abs(evaluation.Summary.Median-0.01) > 1e-9 {
+		t.Fatalf("wanted median 0.01, recieved %f", evaluation.Summary.Median)
+	}
+
+	within := evaluation.Summary.Within
+	if len(within) != 3 || within[0].Percent != 50 || math.Abs(within[1].Percent-200.0/3) > 1e-9 || math.Abs(within[2].Percent-250.0/3) > 1e-9 {
+		t.Fatalf("wanted 50%%, 66.7%% and 83.3%% within tolerances, recieved %+v", within)
+	}
+
+	if evaluation.Summary.Count != 6 || evaluation.Labels["sil"].Count != 4 || evaluation.Labels["a"].Count != 1 {
+		t.Fatalf("wanted per label counts, recieved %+v", evaluation.Labels)
+	}
+}
+
+func TestAligningLabels(t *testing.T) {
+	pairs := alignLabels([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"})
+
+	expected := []labelPair{{0, 0}, {1, 1}, {2, 2}, {-1, 3}}
+	if len(pairs) != len(expected) {
+		t.Fatalf("wanted %v, recieved %v", expected, pairs)
+	}
+	for i, pair := range pairs {
+		if pair != expected[i] {
+			t.Fatalf("wanted %v, recieved %v", expected, pairs)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package htk
+
+import (
+	"fmt"
+	"math"
+)
+
+// FrameRounding is how ToFrames turns the duration of a Lab into a whole number of frames.
+type FrameRounding uint8
+
+const (
+	// RoundNearest rounds the number of frames to the nearest integer.
+	RoundNearest FrameRounding = iota
+	// RoundDown drops a final partial frame.
+	RoundDown
+	// RoundUp keeps a final partial frame.
+	RoundUp
+)
+
+// FramePolicy is how ToFrames labels a frame that straddles the boundary between two annotations.
+type FramePolicy uint8
+
+const (
+	// FrameCenter labels a frame with the Annotation covering its center.
+	FrameCenter FramePolicy = iota
+	// FrameStart labels a frame with the Annotation covering its start.
+	FrameStart
+	// FrameMajority labels a frame with the Annotation covering most of it, preferring the earlier Annotation on ties.
+	FrameMajority
+)
+
+// FrameOption configures how a Lab is converted to and from frames.
+type FrameOption func(*frameConfig)
+
+// frameConfig holds the settings applied by FrameOption functions.
+type frameConfig struct {
+	rounding FrameRounding
+	policy   FramePolicy
+	gap      int
+	gapSet   bool
+}
+
+// WithFrameRounding sets how the number of frames is rounded, which defaults to RoundNearest.
+func WithFrameRounding(rounding FrameRounding) FrameOption {
+	return func(config *frameConfig) {
+		config.rounding = rounding
+	}
+}
+
+// WithFramePolicy sets how frames straddling a boundary are labelled, which defaults to FrameCenter.
+func WithFramePolicy(policy FramePolicy) FrameOption {
+	return func(config *frameConfig) {
+		config.policy = policy
+	}
+}
+
+// WithGapLabel sets the ID given to frames that no Annotation covers. Without it, such frames are an error.
+// FromFrames leaves runs of this ID out of the resulting Lab.
+func WithGapLabel(id int) FrameOption {
+	return func(config *frameConfig) {
+		config.gap = id
+		config.gapSet = true
+	}
+}
+
+// newFrameConfig applies a list of FrameOption functions to the default settings.
+func newFrameConfig(options []FrameOption) frameConfig {
+	config := frameConfig{}
+	for _, option := range options {
+		option(&config)
+	}
+
+	return config
+}
+
+// ToFrames converts the annotations of a Lab into a sequence of label IDs, one for each frame of hopSize samples at a sample rate.
+// The ID of a label is its index in the vocabulary. Frames start at 0 and continue until the end of the last Annotation.
+// Returns an error if a label is not in the vocabulary, or a frame is not covered by any Annotation and no gap label is set.
+func (lab *Lab) ToFrames(hopSize int, sampleRate int, vocabulary []string, options ...FrameOption) ([]int, error) {
+	if hopSize <= 0 || sampleRate <= 0 {
+		return nil, fmt.Errorf("error: cannot convert lab %s to frames with hop size %d and sample rate %d", lab.name, hopSize, sampleRate)
+	}
+	config := newFrameConfig(options)
+
+	ids := make(map[string]int)
+	for id, label := range vocabulary {
+		ids[label] = id
+	}
+
+	frameLength := float64(hopSize) / float64(sampleRate)
+	frameCount := 0
+	labEnd := 0.0
+	if len(lab.annotations) > 0 {
+		labEnd = lab.annotations[len(lab.annotations)-1].end
+		frames := labEnd / frameLength
+		switch config.rounding {
+		case RoundDown:
+			frameCount = int(math.Floor(frames + toleranceEpsilon))
+		case RoundUp:
+			frameCount = int(math.Ceil(frames - toleranceEpsilon))
+		default:
+			frameCount = int(math.Round(frames))
+		}
+	}
+
+	// frames and annotations are both sorted by time, so the annotations of each frame are found by cursors that only move forward
+	latestEnd := lab.latestEnds()
+	after, first, last := 0, 0, 0
+
+	result := make([]int, frameCount)
+	for frame := range result {
+		start := float64(frame) * frameLength
+		// a final partial frame is labelled by the part of it that the lab covers
+		end := math.Min(start+frameLength, labEnd)
+
+		var index int
+		switch config.policy {
+		case FrameMajority:
+			// annotations from first up to but not including last can overlap the frame
+			for first < len(latestEnd) && latestEnd[first] <= start {
+				first++
+			}
+			for last < len(lab.annotations) && lab.annotations[last].start < end {
+				last++
+			}
+			index = lab.majorityBetween(start, end, first, last)
+		default:
+			time := (start + end) / 2
+			if config.policy == FrameStart {
+				time = start
+			}
+
+			for after < len(lab.annotations) && lab.annotations[after].start <= time {
+				after++
+			}
+			index = lab.coveringIndex(time, after)
+		}
+
+		if index == -1 {
+			if !config.gapSet {
+				return nil, fmt.Errorf("error: frame %d of lab %s at %s is not covered by any annotation", frame, lab.name, f2s(start))
+			}
+			result[frame] = config.gap
+			continue
+		}
+
+		id, exists := ids[lab.annotations[index].label]
+		if !exists {
+			return nil, fmt.Errorf("error: label %q of lab %s (line %d) is not in the vocabulary", lab.annotations[index].label, lab.name, lab.annotations[index].line)
+		}
+		result[frame] = id
+	}
+
+	return result, nil
+}
+
+// FromFrames converts a sequence of label IDs, one for each frame of hopSize samples at a sample rate, into a Lab.
+// Each run of the same ID becomes one Annotation, labelled with the entry of the vocabulary at that ID.
+// The resulting Lab has the sample rate set, so its times can be read as samples or frames.
+func FromFrames(frames []int, hopSize int, sampleRate int, vocabulary []string, options ...FrameOption) (Lab, error) {
+	if hopSize <= 0 || sampleRate <= 0 {
+		return Lab{}, fmt.Errorf("error: cannot convert frames with hop size %d and sample rate %d", hopSize, sampleRate)
+	}
+	config := newFrameConfig(options)
+
+	lab := Lab{precision: maxPrecision}
+	frameLength := float64(hopSize) / float64(sampleRate)
+
+	for start := 0; start < len(frames); {
+		id := frames[start]
+
+		end := start + 1
+		for end < len(frames) && frames[end] == id {
+			end++
+		}
+
+		if !config.gapSet || id != config.gap {
+			if id < 0 || id >= len(vocabulary) {
+				return Lab{}, fmt.Errorf("error: frame %d has id %d, which is not in the vocabulary of %d labels", start, id, len(vocabulary))
+			}
+
+			lab.annotations = append(lab.annotations, Annotation{
+				start: float64(start) * frameLength,
+				end:   float64(end) * frameLength,
+				label: vocabulary[id],
+			})
+		}
+
+		start = end
+	}
+
+	lab.SetSampleRate(sampleRate)
+	return lab, nil
+}
+
+// majorityBetween returns the index of the Annotation from first up to but not including last that covers most of the range from start to end,
+// or -1 if none covers it.
+func (lab *Lab) majorityBetween(start float64, end float64, first int, last int) int {
+	best, bestOverlap := -1, 0.0
+
+	for index := first; index < last; index++ {
+		annotation := lab.annotations[index]
+		if annotation.end <= start {
+			continue
+		}
+
+		overlap := math.Min(annotation.end, end) - math.Max(annotation.start, start)
+
+		if overlap > bestOverlap+toleranceEpsilon {
+			best, bestOverlap = index, overlap
+		}
+	}
+
+	return best
+}
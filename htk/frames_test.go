@@ -0,0 +1,157 @@
+package htk
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestToFrames(t *testing.T) {
+	lab := Lab{}
+	lab.SetAnnotations([]Annotation{
+		{start: 0, end: 0.012, label: "sil"},
+		{start: 0.012, end: 0.03, label: "a"},
+		{start: 0.03, end: 0.037, label: "sil"},
+	})
+	vocabulary := []string{"sil", "a"}
+
+	// 80 samples at 16kHz are 5ms frames
+	tests := []struct {
+		options  []FrameOption
+		expected []int
+	}{
+		{nil, []int{0, 0, 1, 1, 1, 1, 0}},
+		{[]FrameOption{WithFramePolicy(FrameStart)}, []int{0, 0, 0, 1, 1, 1, 0}},
+		{[]FrameOption{WithFramePolicy(FrameMajority)}, []int{0, 0, 1, 1, 1, 1, 0}},
+		{[]FrameOption{WithFrameRounding(RoundDown)}, []int{0, 0, 1, 1, 1, 1, 0}},
+		{[]FrameOption{WithFrameRounding(RoundUp)}, []int{0, 0, 1, 1, 1, 1, 0, 0}},
+	}
+
+	for _, test := range tests {
+		frames, err := lab.ToFrames(80, 16000, vocabulary, test.options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(frames) != len(test.expected) {
+			t.Errorf("wanted frames %v, recieved %v", test.expected, frames)
+			continue
+		}
+		for i := range frames {
+			if frames[i] != test.expected[i] {
+				t.Errorf("wanted frames %v, recieved %v", test.expected, frames)
+				break
+			}
+		}
+	}
+
+	_, err := lab.ToFrames(80, 16000, []string{"sil"})
+	if err == nil {
+		t.Fatal("wanted error for label missing from vocabulary")
+	}
+
+	_, err = lab.ToFrames(0, 16000, vocabulary)
+	if err == nil {
+		t.Fatal("wanted error for hop size 0")
+	}
+}
+
+func TestToFramesOverlapping(t *testing.T) {
+	// the long annotation covers the frames after the short ones end
+	lab := Lab{}
+	lab.SetAnnotations([]Annotation{
+		{start: 0, end: 0.04, label: "sil"},
+		{start: 0.005, end: 0.01, label: "a"},
+		{start: 0.01, end: 0.013, label: "a"},
+		{start: 0.03, end: 0.035, label: "a"},
+	})
+	vocabulary := []string{"sil", "a"}
+
+	tests := []struct {
+		options  []FrameOption
+		expected []int
+	}{
+		{nil, []int{0, 1, 1, 0, 0, 0, 1}},
+		{[]FrameOption{WithFramePolicy(FrameStart)}, []int{0, 1, 1, 0, 0, 0, 1}},
+		// ties go to the annotation that starts first
+		{[]FrameOption{WithFramePolicy(FrameMajority)}, []int{0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		frames, err := lab.ToFrames(80, 16000, vocabulary, test.options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(frames, test.expected) {
+			t.Errorf("wanted frames %v, recieved %v", test.expected, frames)
+		}
+	}
+}
+
+func TestToFramesWithGaps(t *testing.T) {
+	lab, err := ReadLab("examples/gaps.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vocabulary := []string{"<gap>", "a", "i"}
+
+	_, err = lab.ToFrames(4800, 48000, vocabulary)
+	if err == nil {
+		t.Fatal("wanted error for frames in gaps")
+	}
+
+	frames, err := lab.ToFrames(4800, 48000, vocabulary, WithGapLabel(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int{0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 0, 0, 2, 2, 2, 2, 2, 2, 2, 2}
+	if len(frames) != len(expected) {
+		t.Fatalf("wanted frames %v, recieved %v", expected, frames)
+	}
+	for i := range frames {
+		if frames[i] != expected[i] {
+			t.Fatalf("wanted frames %v, recieved %v", expected, frames)
+		}
+	}
+
+	// converting back restores the annotations on the frame grid
+	result, err := FromFrames(frames, 4800, 48000, vocabulary, WithGapLabel(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isEqualSlice(result.GetLabels(), lab.GetLabels()) {
+		t.Fatalf("wanted labels %v, recieved %v", lab.GetLabels(), result.GetLabels())
+	}
+	for i, annotation := range result.GetAnnotations() {
+		original := lab.GetAnnotations()[i]
+		start, _ := annotation.GetStartSample()
+		end, _ := annotation.GetEndSample()
+		if start != toSample(original.GetStart(), 48000) || end != toSample(original.GetEnd(), 48000) {
+			t.Fatalf("wanted annotation %d at [%v, %v], recieved [%v, %v]", i, original.GetStart(), original.GetEnd(), annotation.GetStart(), annotation.GetEnd())
+		}
+	}
+}
+
+func TestFromFrames(t *testing.T) {
+	lab, err := FromFrames([]int{0, 0, 1, 1, 1, 0}, 240, 48000, []string{"pau", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isEqualSlice(lab.GetLabels(), []string{"pau", "a", "pau"}) {
+		t.Fatalf("wanted labels [pau a pau], recieved %v", lab.GetLabels())
+	}
+	if end, err := lab.GetAnnotations()[1].GetEndFrame(240); end != 5 || err != nil {
+		t.Fatalf("wanted end frame 5, recieved %d and %v", end, err)
+	}
+	if lab.GetDuration() != 0.03 {
+		t.Fatalf("wanted duration 0.03, recieved %v", lab.GetDuration())
+	}
+
+	_, err = FromFrames([]int{0, 2}, 240, 48000, []string{"pau", "a"})
+	if err == nil {
+		t.Fatal("wanted error for id outside of vocabulary")
+	}
+}
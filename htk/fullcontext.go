@@ -0,0 +1,244 @@
+package htk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HTSEnglishTemplate is the full-context label format of the HTS English demo, where p3 is the current phoneme.
+var HTSEnglishTemplate = mustParseTemplate("hts_english", "p3",
+	"{p1}^{p2}-{p3}+{p4}={p5}@{p6}_{p7}"+
+		"/A:{a1}_{a2}_{a3}"+
+		"/B:{b1}-{b2}-{b3}@{b4}-{b5}&{b6}-{b7}#{b8}-{b9}${b10}-{b11}!{b12}-{b13};{b14}-{b15}|{b16}"+
+		"/C:{c1}+{c2}+{c3}"+
+		"/D:{d1}_{d2}"+
+		"/E:{e1}+{e2}@{e3}+{e4}&{e5}+{e6}#{e7}+{e8}"+
+		"/F:{f1}_{f2}"+
+		"/G:{g1}_{g2}"+
+		"/H:{h1}={h2}@{h3}={h4}|{h5}"+
+		"/I:{i1}={i2}"+
+		"/J:{j1}+{j2}-{j3}")
+
+// SinsyTemplate is the full-context label format of Sinsy, which is also used by NNSVS for Japanese singing voices.
+// p4 is the current phoneme, and e1 is the pitch of the current note.
+var SinsyTemplate = mustParseTemplate("sinsy", "p4",
+	"{p1}@{p2}^{p3}-{p4}+{p5}={p6}_{p7}%{p8}^{p9}_{p10}~{p11}-{p12}!{p13}[{p14}${p15}]{p16}"+
+		"/A:{a1}-{a2}-{a3}@{a4}~{a5}"+
+		"/B:{b1}_{b2}_{b3}@{b4}|{b5}"+
+		"/C:{c1}+{c2}+{c3}@{c4}&{c5}"+
+		"/D:{d1}!{d2}#{d3}${d4}%{d5}|{d6}&{d7};{d8}-{d9}"+
+		"/E:{e1}]{e2}^{e3}={e4}~{e5}!{e6}@{e7}#{e8}+{e9}]{e10}${e11}|{e12}[{e13}&{e14}]{e15}={e16}^{e17}~{e18}#{e19}_{e20}"+
+		";{e21}${e22}&{e23}%{e24}[{e25}|{e26}]{e27}-{e28}^{e29}+{e30}~{e31}={e32}@{e33}${e34}!{e35}%{e36}#{e37}|{e38}|{e39}"+
+		"-{e40}&{e41}&{e42}+{e43}[{e44};{e45}]{e46};{e47}~{e48}~{e49}^{e50}^{e51}@{e52}[{e53}#{e54}={e55}!{e56}~{e57}+{e58}!{e59}^{e60}"+
+		"/F:{f1}#{f2}#{f3}-{f4}${f5}${f6}+{f7}%{f8};{f9}"+
+		"/G:{g1}_{g2}"+
+		"/H:{h1}_{h2}"+
+		"/I:{i1}_{i2}"+
+		"/J:{j1}~{j2}@{j3}")
+
+// ContextTemplate describes the layout of a full-context label, as a sequence of named fields separated by fixed delimiters.
+// Templates are written with field names in braces, such as "{p1}^{p2}-{p3}+{p4}={p5}".
+type ContextTemplate struct {
+	name       string
+	fields     []string
+	delimiters []string
+	indices    map[string]int
+	phoneme    string
+}
+
+// FullContext is a full-context label split into the fields of its ContextTemplate.
+type FullContext struct {
+	template *ContextTemplate
+	values   []string
+}
+
+// NewContextTemplate parses a template pattern into a ContextTemplate. The phoneme field is the field returned by GetPhoneme.
+// Every field must be separated from the next by a delimiter, otherwise labels could not be split unambiguously.
+func NewContextTemplate(name string, phoneme string, pattern string) (ContextTemplate, error) {
+	template := ContextTemplate{name: name, phoneme: phoneme, indices: make(map[string]int)}
+
+	remaining := pattern
+	for {
+		open := strings.Index(remaining, "{")
+		if open == -1 {
+			template.delimiters = append(template.delimiters, remaining)
+			break
+		}
+
+		closing := strings.Index(remaining[open:], "}")
+		if closing == -1 {
+			return template, fmt.Errorf("error: malformed context template %s: unclosed field at %q", name, remaining[open:])
+		}
+		closing += open
+
+		delimiter := remaining[:open]
+		field := remaining[open+1 : closing]
+
+		if field == "" {
+			return template, fmt.Errorf("error: malformed context template %s: empty field name", name)
+		}
+		if _, exists := template.indices[field]; exists {
+			return template, fmt.Errorf("error: malformed context template %s: field %s is defined twice", name, field)
+		}
+		if delimiter == "" && len(template.fields) > 0 {
+			return template, fmt.Errorf("error: malformed context template %s: no delimiter between %s and %s", name, template.fields[len(template.fields)-1], field)
+		}
+
+		template.indices[field] = len(template.fields)
+		template.fields = append(template.fields, field)
+		template.delimiters = append(template.delimiters, delimiter)
+		remaining = remaining[closing+1:]
+	}
+
+	if len(template.fields) == 0 {
+		return template, fmt.Errorf("error: malformed context template %s: no fields", name)
+	}
+	if _, exists := template.indices[phoneme]; !exists {
+		return template, fmt.Errorf("error: malformed context template %s: phoneme field %s is not defined", name, phoneme)
+	}
+
+	return template, nil
+}
+
+// mustParseTemplate parses one of the built-in templates, which are known to be valid.
+func mustParseTemplate(name string, phoneme string, pattern string) ContextTemplate {
+	template, err := NewContextTemplate(name, phoneme, pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return template
+}
+
+// GetName gets the name of a ContextTemplate.
+func (template *ContextTemplate) GetName() string {
+	return template.name
+}
+
+// GetFields gets the names of every field of a ContextTemplate, in the order they appear in a label.
+func (template *ContextTemplate) GetFields() []string {
+	return append([]string(nil), template.fields...)
+}
+
+// GetPhonemeField gets the name of the field holding the current phoneme.
+func (template *ContextTemplate) GetPhonemeField() string {
+	return template.phoneme
+}
+
+// Parse splits a full-context label into the fields of a ContextTemplate.
+// Each field runs until the next occurrence of the delimiter that follows it, so a field value may contain any other delimiter.
+func (template *ContextTemplate) Parse(label string) (FullContext, error) {
+	context := FullContext{template: template, values: make([]string, len(template.fields))}
+
+	remaining, found := strings.CutPrefix(label, template.delimiters[0])
+	if !found {
+		return context, fmt.Errorf("error: label %q does not match context template %s: missing %q at the start", label, template.name, template.delimiters[0])
+	}
+
+	for i := range template.fields {
+		next := template.delimiters[i+1]
+
+		// the last field runs until the trailing delimiter, or the end of the label
+		if i == len(template.fields)-1 {
+			value, found := strings.CutSuffix(remaining, next)
+			if !found {
+				return context, fmt.Errorf("error: label %q does not match context template %s: missing %q at the end", label, template.name, next)
+			}
+			context.values[i] = value
+			break
+		}
+
+		end := strings.Index(remaining, next)
+		if end == -1 {
+			return context, fmt.Errorf("error: label %q does not match context template %s: missing %q after %s", label, template.name, next, template.fields[i])
+		}
+
+		context.values[i] = remaining[:end]
+		remaining = remaining[end+len(next):]
+	}
+
+	return context, nil
+}
+
+// GetTemplate gets the ContextTemplate a FullContext was parsed with.
+func (context *FullContext) GetTemplate() *ContextTemplate {
+	return context.template
+}
+
+// Get gets the value of a field of a FullContext. Returns false if the template has no such field.
+func (context *FullContext) Get(field string) (string, bool) {
+	index, exists := context.template.indices[field]
+	if !exists {
+		return "", false
+	}
+
+	return context.values[index], true
+}
+
+// GetInt gets the value of a field of a FullContext as an integer.
+// Returns false if the template has no such field, or if the value is not an integer, such as the undefined values "x" and "xx".
+func (context *FullContext) GetInt(field string) (int, bool) {
+	value, exists := context.Get(field)
+	if !exists {
+		return 0, false
+	}
+
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return result, true
+}
+
+// Set sets the value of a field of a FullContext. Returns an error if the template has no such field.
+func (context *FullContext) Set(field string, value string) error {
+	index, exists := context.template.indices[field]
+	if !exists {
+		return fmt.Errorf("error: context template %s has no field %s", context.template.name, field)
+	}
+
+	context.values[index] = value
+	return nil
+}
+
+// GetPhoneme gets the current phoneme of a FullContext.
+func (context *FullContext) GetPhoneme() string {
+	value, _ := context.Get(context.template.phoneme)
+	return value
+}
+
+// SetPhoneme sets the current phoneme of a FullContext.
+func (context *FullContext) SetPhoneme(phoneme string) {
+	_ = context.Set(context.template.phoneme, phoneme)
+}
+
+// String formats a FullContext back into a full-context label.
+func (context *FullContext) String() string {
+	var builder strings.Builder
+
+	for i, value := range context.values {
+		builder.WriteString(context.template.delimiters[i])
+		builder.WriteString(value)
+	}
+	builder.WriteString(context.template.delimiters[len(context.values)])
+
+	return builder.String()
+}
+
+// GetFullContexts parses the label of every Annotation of a Lab with a ContextTemplate.
+func (lab *Lab) GetFullContexts(template ContextTemplate) ([]FullContext, error) {
+	var contexts []FullContext
+
+	for i, annotation := range lab.annotations {
+		context, err := template.Parse(annotation.label)
+		if err != nil {
+			return nil, fmt.Errorf("error: annotation %d of lab %s (line %d): %v", i, lab.name, annotation.line, err)
+		}
+
+		contexts = append(contexts, context)
+	}
+
+	return contexts, nil
+}
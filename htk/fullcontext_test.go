@@ -0,0 +1,142 @@
+package htk
+
+import "testing"
+
+func TestParsingHTSEnglish(t *testing.T) {
+	lab, err := ReadLab("examples/fullcontext/hts_english.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contexts, err := lab.GetFullContexts(HTSEnglishTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(contexts) != 3 {
+		t.Fatalf("wanted 3 contexts, recieved %d", len(contexts))
+	}
+
+	if contexts[1].GetPhoneme() != "ao" {
+		t.Fatalf("wanted phoneme ao, recieved %s", contexts[1].GetPhoneme())
+	}
+
+	next, _ := contexts[1].Get("p4")
+	if next != "th" {
+		t.Fatalf("wanted next phoneme th, recieved %s", next)
+	}
+
+	// p6 is the position of the phoneme in its syllable
+	position, ok := contexts[2].GetInt("p6")
+	if !ok || position != 2 {
+		t.Fatalf("wanted position 2, recieved %d", position)
+	}
+
+	if _, ok := contexts[0].GetInt("p6"); ok {
+		t.Fatal("wanted undefined value x to not be an integer")
+	}
+
+	accent, _ := contexts[1].Get("h5")
+	if accent != "L-L%" {
+		t.Fatalf("wanted tone L-L%%, recieved %s", accent)
+	}
+
+	for i, context := range contexts {
+		if context.String() != lab.GetAnnotations()[i].GetLabel() {
+			t.Fatalf("wanted formatted label %s, recieved %s", lab.GetAnnotations()[i].GetLabel(), context.String())
+		}
+	}
+}
+
+func TestParsingSinsy(t *testing.T) {
+	lab, err := ReadLab("examples/fullcontext/sinsy.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contexts, err := lab.GetFullContexts(SinsyTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if contexts[0].GetPhoneme() != "sil" || contexts[1].GetPhoneme() != "k" {
+		t.Fatalf("wanted phonemes sil and k, recieved %s and %s", contexts[0].GetPhoneme(), contexts[1].GetPhoneme())
+	}
+
+	pitch, _ := contexts[1].Get("e1")
+	if pitch != "E4" {
+		t.Fatalf("wanted note pitch E4, recieved %s", pitch)
+	}
+
+	// the time signature contains a slash, which is also part of the section delimiters
+	signature, _ := contexts[1].Get("e4")
+	if signature != "4/4" {
+		t.Fatalf("wanted time signature 4/4, recieved %s", signature)
+	}
+
+	tempo, ok := contexts[1].GetInt("e5")
+	if !ok || tempo != 100 {
+		t.Fatalf("wanted tempo 100, recieved %d", tempo)
+	}
+
+	if len(SinsyTemplate.GetFields()) != 118 {
+		t.Fatalf("wanted 118 fields, recieved %d", len(SinsyTemplate.GetFields()))
+	}
+
+	if contexts[1].String() != lab.GetAnnotations()[1].GetLabel() {
+		t.Fatalf("wanted formatted label %s, recieved %s", lab.GetAnnotations()[1].GetLabel(), contexts[1].String())
+	}
+}
+
+func TestEditingFullContext(t *testing.T) {
+	context, err := HTSEnglishTemplate.Parse("x^pau-ao+th=er@1_2/A:0_0_0/B:1-1-2@1-2&1-7#1-4$1-3!0-1;0-3|ao/C:0+0+2/D:0_0/E:content+2@1+7&1+4#0+1/F:in_1/G:0_0/H:7=5@1=2|0/I:7=3/J:17+11-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context.SetPhoneme("aa")
+	err = context.Set("j1", "18")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "x^pau-aa+th=er@1_2/A:0_0_0/B:1-1-2@1-2&1-7#1-4$1-3!0-1;0-3|ao/C:0+0+2/D:0_0/E:content+2@1+7&1+4#0+1/F:in_1/G:0_0/H:7=5@1=2|0/I:7=3/J:18+11-2"
+	if context.String() != expected {
+		t.Fatalf("wanted %s, recieved %s", expected, context.String())
+	}
+
+	if context.Set("z9", "1") == nil {
+		t.Fatal("wanted error for unknown field")
+	}
+
+	_, err = HTSEnglishTemplate.Parse("a")
+	if err == nil {
+		t.Fatal("wanted error for monophone label")
+	}
+}
+
+func TestCustomTemplate(t *testing.T) {
+	template, err := NewContextTemplate("triphone", "center", "{left}-{center}+{right}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context, err := template.Parse("k-a+t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if context.GetPhoneme() != "a" {
+		t.Fatalf("wanted phoneme a, recieved %s", context.GetPhoneme())
+	}
+
+	_, err = NewContextTemplate("broken", "a", "{a}{b}")
+	if err == nil {
+		t.Fatal("wanted error for fields without delimiter")
+	}
+
+	_, err = NewContextTemplate("broken", "c", "{a}-{b}")
+	if err == nil {
+		t.Fatal("wanted error for undefined phoneme field")
+	}
+}
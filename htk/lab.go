@@ -2,25 +2,39 @@ package htk
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/vocatart/golab/fileio"
 )
 
 // Lab structs are a collection of annotations.
+// A Lab can also hold alternative transcriptions, of which the annotations are the currently selected one.
 // The HTK Label format is defined at http://www.seas.ucla.edu/spapl/weichu/htkbook/node113_mn.html
 type Lab struct {
-	annotations []Annotation
-	name        string
-	precision   uint8
+	annotations  []Annotation
+	alternatives [][]Annotation
+	name         string
+	precision    uint8
+	unit         TimeUnit
+	sampleRate   int
+	encoding     Encoding
+	span         float64
+	ends         []float64
 }
 
 // SetAnnotations sets the annotations field in a Lab.
+// Annotations without a sample rate take the sample rate of the Lab, like PushAnnotation.
 func (lab *Lab) SetAnnotations(annotations []Annotation) {
-	lab.annotations = annotations
+	lab.annotations = lab.withSampleRate(annotations)
+	lab.ends = nil
+	lab.span = 0
 }
 
 // GetAnnotations gets the annotations field in a Lab.
@@ -29,18 +43,42 @@ func (lab *Lab) GetAnnotations() []Annotation {
 }
 
 // PushAnnotation pushes a single Annotation into the annotations field of a Lab.
+// An Annotation without a sample rate takes the sample rate of the Lab.
 func (lab *Lab) PushAnnotation(annotation Annotation) {
+	if annotation.sampleRate == 0 {
+		annotation.sampleRate = lab.sampleRate
+	}
+
 	lab.annotations = append(lab.annotations, annotation)
+	lab.ends = nil
+}
+
+// withSampleRate returns a copy of an Annotation slice, giving the sample rate of a Lab to every Annotation that has none.
+// The slice of the caller is left unchanged.
+func (lab *Lab) withSampleRate(annotations []Annotation) []Annotation {
+	result := append([]Annotation(nil), annotations...)
+	for i := range result {
+		if result[i].sampleRate == 0 {
+			result[i].sampleRate = lab.sampleRate
+		}
+	}
+
+	return result
 }
 
 // AppendAnnotations appends an Annotation slice to the annotations field in a Lab.
+// Annotations without a sample rate take the sample rate of the Lab.
 func (lab *Lab) AppendAnnotations(annotations []Annotation) {
-	lab.annotations = append(lab.annotations, annotations...)
+	for _, annotation := range annotations {
+		lab.PushAnnotation(annotation)
+	}
 }
 
 // ClearAnnotations removes all annotations in a Lab.
 func (lab *Lab) ClearAnnotations() {
 	lab.annotations = nil
+	lab.ends = nil
+	lab.span = 0
 }
 
 // GetLabels returns the annotations field in a Lab as a slice of strings.
@@ -75,7 +113,12 @@ func (lab *Lab) SetPrecision(precision uint8) {
 }
 
 // GetDuration gets the total duration of a Lab by getting the difference in global start and end.
+// Returns 0 if the Lab has no annotations.
 func (lab *Lab) GetDuration() (result float64) {
+	if len(lab.annotations) == 0 {
+		return 0
+	}
+
 	// calculate using start and end in case lab file doesn't start at 0
 	start := lab.annotations[0].start
 	end := lab.annotations[len(lab.annotations)-1].end
@@ -83,20 +126,28 @@ func (lab *Lab) GetDuration() (result float64) {
 	return end - start
 }
 
+// GetSpan gets the length of the chunk of audio a Lab covers, such as a part created by SplitAt, which Concatenate uses to place the next Lab.
+// Returns 0 if it is not known, in which case the Lab ends with its last Annotation. Setting or clearing the annotations of a Lab resets it.
+func (lab *Lab) GetSpan() float64 {
+	return lab.span
+}
+
+// SetSpan sets the length of the chunk of audio a Lab covers. See GetSpan.
+func (lab *Lab) SetSpan(span float64) {
+	lab.span = span
+}
+
 // GetLength gets the total amount of annotations in a Lab.
 func (lab *Lab) GetLength() (result int) {
 	return len(lab.annotations)
 }
 
-// ReadLab takes a path to a .lab file and reads its contents into a Lab.
-func ReadLab(path string) (Lab, error) {
-	lab := Lab{}
-	parsedPrecision := false
-
+// ReadLab takes a path to a .lab file and reads its contents into a Lab. See ParseLab for the accepted format.
+func ReadLab(path string, options ...ReadOption) (Lab, error) {
 	// check if the file exists
 	labData, err := os.Open(path)
 	if err != nil {
-		return lab, err
+		return Lab{}, err
 	}
 	defer func() {
 		closingError := labData.Close()
@@ -105,44 +156,151 @@ func ReadLab(path string) (Lab, error) {
 		}
 	}()
 
-	// iterate through the file and write to lab object
-	line := bufio.NewScanner(labData)
+	lab, err := ParseLab(labData, filepath.Base(path), options...)
+
+	// errors point to the path that was given, rather than the name of the lab
+	var parseError *ParseError
+	if errors.As(err, &parseError) {
+		parseError.Path = path
+	}
+
+	return lab, err
+}
+
+// ParseLab reads the contents of an HTK label file from an io.Reader into a Lab with the given name.
+// Lines follow the HTK label format `[start [end]] name [score] {auxname [auxscore]} [;comment]`.
+// The name is required, so a line with only a start and end time is an error rather than a numeric name.
+// A missing start time is taken from the end of the previous Annotation, and a missing end time from the start of the next one.
+// Unless a TimeUnit is given with WithTimeUnit, files with only integer times are read as HTKUnits, and all other files as Seconds.
+// Unless an Encoding is given with WithEncoding, the Encoding is detected, see DetectEncoding.
+func ParseLab(reader io.Reader, name string, options ...ReadOption) (Lab, error) {
+	config := newReadConfig(options)
+
+	decoded, enc, err := decodeContent(reader, config)
+	if err != nil {
+		return Lab{name: name}, &ParseError{Path: name, Field: "encoding", Cause: err}
+	}
+
+	// read every line before parsing
+	var lines []string
+	line := bufio.NewScanner(decoded)
 	for line.Scan() {
-		// split by whitespace
-		labLine := strings.Split(line.Text(), " ")
+		lines = append(lines, line.Text())
+	}
+	if err := line.Err(); err != nil {
+		return Lab{name: name}, err
+	}
 
-		// the lab is malformed if there are less than 3 elements per line
-		if len(labLine) < 3 {
-			return lab, fmt.Errorf("error: malformed lab file %s", path)
+	lab, parseError := parseLabLines(lines, 1, config)
+	lab.name = name
+	lab.encoding = enc
+	if parseError != nil {
+		parseError.Path = name
+		return lab, parseError
+	}
+
+	return lab, nil
+}
+
+// parseLabLines converts the lines of an HTK label file into a Lab, without setting its name.
+// firstLine is the line number of the first line in the file, which is stored in each Annotation and any ParseError.
+func parseLabLines(lines []string, firstLine int, config readConfig) (Lab, *ParseError) {
+	lab := Lab{}
+	parsedPrecision := false
+	integerTimes := true
+
+	// every alternative transcription is read into its own list, the first one being the annotations of the Lab
+	lists := [][]Annotation{nil}
+
+	// keeps track of which annotations did not have an end time in the file
+	missingEnd := [][]bool{nil}
+
+	for lineIndex, line := range lines {
+		// split by whitespace, keeping the column of each field for errors
+		labLine, columns := splitFields(line)
+
+		// skip empty lines
+		if len(labLine) == 0 {
+			continue
 		}
 
-		// parse the precision if it hasn't been parsed yet
-		if !parsedPrecision {
-			lab.parsePrecision(labLine[1])
-			parsedPrecision = true
+		// a separator starts the next alternative transcription
+		if len(labLine) == 1 && labLine[0] == alternativeSeparator {
+			lists = append(lists, nil)
+			missingEnd = append(missingEnd, nil)
+			continue
 		}
 
-		// parse the start and end times
-		start, err := strconv.ParseFloat(labLine[0], 64)
+		annotation, timeCount, err := parseAnnotation(labLine, columns)
 		if err != nil {
+			err.Line = firstLine + lineIndex
 			return lab, err
 		}
-		end, err := strconv.ParseFloat(labLine[1], 64)
-		if err != nil {
-			return lab, err
+		annotation.line = firstLine + lineIndex
+
+		// parse the precision if it hasn't been parsed yet, using the last time field on the line
+		if !parsedPrecision && timeCount > 0 {
+			lab.parsePrecision(labLine[timeCount-1])
+			parsedPrecision = true
+		}
+
+		// the time unit is detected from every time field in the file
+		for _, field := range labLine[:timeCount] {
+			integerTimes = integerTimes && isIntegerTime(field)
+		}
+
+		// a missing start time continues from the end of the previous annotation
+		current := len(lists) - 1
+		if timeCount == 0 && len(lists[current]) > 0 {
+			annotation.start = lists[current][len(lists[current])-1].end
 		}
 
-		// join the rest of the line into a single string and add it to the annotations
-		label := strings.Join(labLine[2:], " ")
-		lab.annotations = append(lab.annotations, Annotation{start: start, end: end, label: label})
+		lists[current] = append(lists[current], annotation)
+		missingEnd[current] = append(missingEnd[current], timeCount < 2)
 	}
 
-	lab.name = filepath.Base(path)
+	for i := range lists {
+		resolveEndTimes(lists[i], missingEnd[i])
+	}
 
-	return lab, err
+	if !parsedPrecision {
+		lab.precision = 7
+	}
+
+	// convert the times from the unit of the file into seconds
+	lab.unit = config.unit
+	if !config.unitSet && parsedPrecision && integerTimes {
+		lab.unit = HTKUnits
+	}
+	lab.sampleRate = config.sampleRate
+
+	scale, err := lab.unitsPerSecond()
+	if err != nil {
+		return lab, &ParseError{Field: "time unit", Cause: err}
+	}
+	for _, list := range lists {
+		for i := range list {
+			list[i].start /= scale
+			list[i].end /= scale
+		}
+	}
+
+	lab.annotations = lists[0]
+	lab.alternatives = lists[1:]
+
+	// snap to the sample grid so repeated reads and writes cannot drift
+	if config.sampleRate > 0 {
+		lab.SetSampleRate(config.sampleRate)
+		err = lab.SnapToSamples()
+		if err != nil {
+			return lab, &ParseError{Field: "sample rate", Cause: err}
+		}
+	}
+
+	return lab, nil
 }
 
-// WriteLab writes a Lab to a file from a given path. If the file already exists, it will be overwritten unless overwrite is set to false.
+// WriteLab writes a Lab to a file from a given path, using the TimeUnit of the Lab. If the file already exists, it will be overwritten only if overwrite is set to true.
 // If the path is a directory, the contents will be written to a file with the same name as the Lab, in the directory.
 func (lab *Lab) WriteLab(path string, overwrite ...bool) error {
 	// if no overwrite is specified, default to false
@@ -150,79 +308,195 @@ func (lab *Lab) WriteLab(path string, overwrite ...bool) error {
 		overwrite = append(overwrite, false)
 	}
 
-	// replace backslashes with forward slashes
-	path = strings.Replace(path, "\\", "/", -1)
+	return lab.WriteLabWith(path, fileio.WithOverwrite(overwrite[0]))
+}
+
+// WriteLabWith writes a Lab to a file from a given path like WriteLab, following the given write options.
+// By default, existing files are not overwritten, missing directories are created and the file is written atomically.
+func (lab *Lab) WriteLabWith(path string, options ...fileio.WriteOption) error {
+	// make sure the times can be written before creating the file
+	if _, err := lab.unitsPerSecond(); err != nil {
+		return err
+	}
+
+	return fileio.WriteFile(fileio.ResolvePath(path, lab.name+".lab"), func(writer io.Writer) error {
+		_, err := lab.WriteTo(writer)
+		return err
+	}, options...)
+}
 
-	// initialize the filename
-	var fileName string
+// WriteTo writes a Lab to an io.Writer in the HTK label format, using the TimeUnit of the Lab.
+// Returns the number of bytes written, implementing io.WriterTo.
+func (lab *Lab) WriteTo(writer io.Writer) (int64, error) {
+	var written int64
 
-	// check if path exists, if it doesn't, make it.
-	pathInfo, err := os.Stat(path)
+	if _, err := lab.unitsPerSecond(); err != nil {
+		return written, err
+	}
+
+	content, err := lab.encoding.encode(lab.ToString())
 	if err != nil {
-		if filepath.Ext(path) != "" {
-			// if the path is a file, make the directory it is in
-			pathSplit := strings.Split(path, "/")
-			err := os.MkdirAll(strings.Join(pathSplit[0:len(pathSplit)-1], "/"), os.ModePerm)
-			if err != nil {
-				return err
-			}
-		} else {
-			// if the path is a directory, make it
-			err := os.MkdirAll(path, os.ModePerm)
-			if err != nil {
-				return err
-			}
+		return written, fmt.Errorf("error writing lab %s: %v", lab.name, err)
+	}
+
+	count, err := writer.Write(content)
+	written += int64(count)
+	return written, err
+}
+
+// ToString converts a lab to a string, using the TimeUnit of the Lab.
+func (lab *Lab) ToString() string {
+	var result string
+
+	for _, line := range lab.formatLines() {
+		result += line + "\n"
+	}
+
+	return result
+}
+
+// formatLines converts the annotations of a Lab into the lines of an HTK label file, including any alternative transcriptions.
+func (lab *Lab) formatLines() []string {
+	var lines []string
+
+	for _, annotation := range lab.annotations {
+		lines = append(lines, lab.formatAnnotation(annotation))
+	}
+
+	for _, alternative := range lab.alternatives {
+		lines = append(lines, alternativeSeparator)
+		for _, annotation := range alternative {
+			lines = append(lines, lab.formatAnnotation(annotation))
 		}
 	}
 
-	// if path is a directory, construct the desired filename. if path is a file, make it.
-	if filepath.Ext(path) == "" || (pathInfo != nil && pathInfo.IsDir()) {
-		// create the filename if the destination is a directory
-		fileName = filepath.Join(path, lab.name+".lab")
-	} else {
-		// if the path is a file, check if it already exists and if overwrite is false
-		if pathInfo != nil && !overwrite[0] {
-			log.Fatalf("error: file %s already exists", path)
+	return lines
+}
+
+// formatAnnotation converts an Annotation into a single line of an HTK label file, using the TimeUnit and precision of the Lab.
+func (lab *Lab) formatAnnotation(annotation Annotation) string {
+	fields := []string{
+		lab.formatTime(annotation.start),
+		lab.formatTime(annotation.end),
+		annotation.label,
+	}
+
+	if annotation.hasScore {
+		fields = append(fields, f2s(annotation.score))
+	}
+
+	for _, auxiliary := range annotation.auxiliaries {
+		fields = append(fields, auxiliary.name)
+		if auxiliary.hasScore {
+			fields = append(fields, f2s(auxiliary.score))
 		}
-		fileName = path
 	}
 
-	// create the file with the filename defined above
-	file, err := os.Create(fileName)
-	if err != nil {
-		log.Fatal(err)
+	if annotation.comment != "" {
+		fields = append(fields, "; "+annotation.comment)
 	}
-	defer func(file *os.File) {
-		closingError := file.Close()
-		if err == nil {
-			err = closingError
+
+	return strings.Join(fields, " ")
+}
+
+// parseAnnotation converts the whitespace separated fields of a single HTK label line into an Annotation.
+// Columns holds the column of each field, which is used in the returned ParseError. The line number is left to the caller.
+// Also returns how many time fields (0, 1 or 2) were present on the line.
+func parseAnnotation(fields []string, columns []int) (Annotation, int, *ParseError) {
+	annotation := Annotation{}
+	index := 0
+
+	// the column just after the last field, where a missing field would have been
+	lineEnd := columns[len(columns)-1] + utf8.RuneCountInString(fields[len(fields)-1])
+
+	// a line of only a start and end time is missing its name, even though the end time could be read as a numeric name
+	if len(fields) == 2 && isNumeric(fields[0]) && isNumeric(fields[1]) {
+		return annotation, 0, &ParseError{Column: lineEnd, Field: "label name", Cause: fmt.Errorf("missing label name in line %q", strings.Join(fields, " "))}
+	}
+
+	// the start and end times are both optional, but the end time can only be present with a start time
+	timeCount := 0
+	for timeCount < 2 && index < len(fields)-1 && isNumeric(fields[index]) {
+		time, err := strconv.ParseFloat(fields[index], 64)
+		if err != nil {
+			field := "start time"
+			if timeCount == 1 {
+				field = "end time"
+			}
+			return annotation, 0, &ParseError{Column: columns[index], Field: field, Cause: err}
 		}
-	}(file)
 
-	// iterate through the annotations and write them to the file
-	precision := lab.precision
+		if timeCount == 0 {
+			annotation.start = time
+		} else {
+			annotation.end = time
+		}
+
+		timeCount++
+		index++
+	}
 
-	for _, labEntry := range lab.annotations {
-		start := labEntry.start
-		end := labEntry.end
+	// the name is the only required field
+	if index >= len(fields) || strings.HasPrefix(fields[index], ";") {
+		column := lineEnd
+		if index < len(fields) {
+			column = columns[index]
+		}
+		return annotation, 0, &ParseError{Column: column, Field: "label name", Cause: fmt.Errorf("missing label name in line %q", strings.Join(fields, " "))}
+	}
+	annotation.label = fields[index]
+	index++
 
-		_, err := fmt.Fprintln(file, strconv.FormatFloat(start, 'f', int(precision), 64), strconv.FormatFloat(end, 'f', int(precision), 64), labEntry.label)
+	// an optional score directly follows the name
+	if index < len(fields) && isNumeric(fields[index]) {
+		score, err := strconv.ParseFloat(fields[index], 64)
 		if err != nil {
-			return err
+			return annotation, 0, &ParseError{Column: columns[index], Field: "score", Cause: err}
 		}
+		annotation.SetScore(score)
+		index++
 	}
-	return nil
-}
 
-// ToString converts a lab to a string.
-func (lab *Lab) ToString() string {
-	var result string
+	for index < len(fields) {
+		// everything after a semicolon is a comment
+		if strings.HasPrefix(fields[index], ";") {
+			annotation.comment = strings.TrimSpace(strings.TrimPrefix(strings.Join(fields[index:], " "), ";"))
+			break
+		}
 
-	for _, annotation := range lab.annotations {
-		result += fmt.Sprintf("%s %s %s\n", strconv.FormatFloat(annotation.start, 'f', int(lab.precision), 64), strconv.FormatFloat(annotation.end, 'f', int(lab.precision), 64), annotation.label)
+		// any other field is an auxiliary name, optionally followed by its score
+		auxiliary := Auxiliary{name: fields[index]}
+		index++
+
+		if index < len(fields) && isNumeric(fields[index]) {
+			score, err := strconv.ParseFloat(fields[index], 64)
+			if err != nil {
+				return annotation, 0, &ParseError{Column: columns[index], Field: "auxiliary score", Cause: err}
+			}
+			auxiliary.SetScore(score)
+			index++
+		}
+
+		annotation.auxiliaries = append(annotation.auxiliaries, auxiliary)
 	}
 
-	return result
+	return annotation, timeCount, nil
+}
+
+// resolveEndTimes fills in end times that were missing from a label file with the start time of the following Annotation.
+// The last Annotation ends at its own start time if its end time is missing.
+func resolveEndTimes(annotations []Annotation, missingEnd []bool) {
+	for i := range annotations {
+		if !missingEnd[i] {
+			continue
+		}
+
+		if i+1 < len(annotations) {
+			annotations[i].end = annotations[i+1].start
+		} else {
+			annotations[i].end = annotations[i].start
+		}
+	}
 }
 
 // parsePrecision retrieves the precision field of a Lab based on the context of the time durations.
@@ -237,6 +511,22 @@ func (lab *Lab) parsePrecision(secondTime string) {
 	lab.precision = uint8(len(secondTime) - periodIndex - 1)
 }
 
+// isNumeric returns true if a label field is a plain decimal number.
+// Special values such as "inf" and "nan" are not considered numeric, so they can still be used as labels.
+func isNumeric(field string) bool {
+	if field == "" || !strings.ContainsRune("0123456789+-.", rune(field[0])) {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(field, 64)
+	return err == nil
+}
+
+// f2s converts float into string with preservation.
+func f2s(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
 // isEqualSlice compares two slices, returning true if they are identical.
 func isEqualSlice(slice1, slice2 []string) bool {
 	// if they aren't the same length, we return false right away
@@ -1,6 +1,15 @@
 package htk
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/vocatart/golab/fileio"
+)
 
 func TestReadingLab(t *testing.T) {
 	lab, err := ReadLab("examples/short.lab")
@@ -241,3 +250,131 @@ func TestGettingLabLength(t *testing.T) {
 		t.Fatalf("wanted length of %d, recieved %d", trueLength, lab.GetLength())
 	}
 }
+
+func TestReadingFullLabelGrammar(t *testing.T) {
+	lab, err := ReadLab("examples/hvite.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := lab.GetAnnotations()[0]
+	if first.GetLabel() != "sil" || first.GetScore() != -1523.471191 {
+		t.Fatalf("wanted label sil with score -1523.471191, recieved %s with score %f", first.GetLabel(), first.GetScore())
+	} else if first.GetAuxiliaries()[0].GetName() != "SENT-START" || first.GetAuxiliaries()[0].HasScore() {
+		t.Fatalf("wanted auxiliary SENT-START without score, recieved %v", first.GetAuxiliaries())
+	} else if first.GetComment() != "start of utterance" {
+		t.Fatalf("wanted comment \"start of utterance\", recieved %q", first.GetComment())
+	}
+
+	second := lab.GetAnnotations()[1]
+	if second.GetAuxiliaries()[0].GetName() != "HELLO" || second.GetAuxiliaries()[0].GetScore() != -720.5 {
+		t.Fatalf("wanted auxiliary HELLO with score -720.5, recieved %v", second.GetAuxiliaries())
+	}
+
+	if lab.ToString() != "0 2500000 sil -1523.471191 SENT-START ; start of utterance\n2500000 3900000 h -302.125 HELLO -720.5\n3900000 5100000 ax -210\n5100000 6000000 l -188.25 WORLD\n6000000 8100000 sil -410.75 SENT-END\n" {
+		t.Fatalf("full label grammar did not round-trip, recieved:\n%s", lab.ToString())
+	}
+}
+
+func TestReadingMissingTimes(t *testing.T) {
+	lab, err := ReadLab("examples/missing_times.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.GetLength() != 3 {
+		t.Fatalf("wanted 3 annotations, recieved %d", lab.GetLength())
+	} else if lab.GetAnnotations()[0].GetEnd() != 1.5 {
+		t.Fatalf("wanted end time 1.5 from next start, recieved %f", lab.GetAnnotations()[0].GetEnd())
+	} else if lab.GetAnnotations()[2].GetStart() != 2.5 || lab.GetAnnotations()[2].GetEnd() != 2.5 {
+		t.Fatalf("wanted last annotation at 2.5, recieved [%f, %f]", lab.GetAnnotations()[2].GetStart(), lab.GetAnnotations()[2].GetEnd())
+	}
+}
+
+func TestReadingMissingLabelName(t *testing.T) {
+	// a line with only a start and end time is missing its name, rather than having a numeric name
+	_, err := ParseLab(strings.NewReader("0 1 a\n1 2\n"), "missing.lab")
+	if err == nil || !strings.Contains(err.Error(), "missing label name") {
+		t.Fatalf("wanted missing label name error, recieved %v", err)
+	}
+
+	// a single number is still a name, and a number after a name is its score
+	lab, err := ParseLab(strings.NewReader("7\n0 1 a 3.5\n"), "numeric.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lab.GetLabels()[0] != "7" || lab.GetAnnotations()[1].GetLabel() != "a" || lab.GetAnnotations()[1].GetScore() != 3.5 {
+		t.Fatalf("wanted name 7 and name a with score 3.5, recieved %v", lab.GetAnnotations())
+	}
+
+	// a number after both times is a name, such as a word ID
+	lab, err = ParseLab(strings.NewReader("0 1 2\n1 2 2 3.5\n"), "ids.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEqualSlice(lab.GetLabels(), []string{"2", "2"}) || lab.GetAnnotations()[1].GetScore() != 3.5 {
+		t.Fatalf("wanted names 2 and 2 with score 3.5, recieved %v", lab.GetAnnotations())
+	}
+}
+
+func TestParsingLabFromReader(t *testing.T) {
+	lab, err := ParseLab(strings.NewReader("0.00 0.50 sil\n0.50 1.00 a\n"), "reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.GetName() != "reader" {
+		t.Fatalf("wanted name reader, recieved %s", lab.GetName())
+	} else if !isEqualSlice(lab.GetLabels(), []string{"sil", "a"}) {
+		t.Fatalf("wanted labels sil and a, recieved %v", lab.GetLabels())
+	}
+
+	_, err = ParseLab(strings.NewReader("0.00 0.50\n"), "malformed")
+	if err == nil {
+		t.Fatal("wanted error for line without a label")
+	}
+}
+
+func TestWritingLabToWriter(t *testing.T) {
+	lab, err := ReadLab("examples/short.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var writer io.WriterTo = &lab
+	var buffer bytes.Buffer
+
+	written, err := writer.WriteTo(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buffer.String() != lab.ToString() {
+		t.Fatalf("wanted %q, recieved %q", lab.ToString(), buffer.String())
+	} else if written != int64(buffer.Len()) {
+		t.Fatalf("wanted %d bytes written, recieved %d", buffer.Len(), written)
+	}
+
+	lab.SetTimeUnit(Samples)
+	_, err = lab.WriteTo(&buffer)
+	if err == nil {
+		t.Fatal("wanted error when writing samples without a sample rate")
+	}
+}
+
+func TestWritingExistingLab(t *testing.T) {
+	lab, err := ReadLab("examples/short.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = lab.WriteLab("examples/output.lab")
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("wanted error for existing file, recieved %v", err)
+	}
+
+	err = lab.WriteLabWith("examples/output.lab", fileio.WithOverwrite(true), fileio.WithDryRun(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,281 @@
+package htk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// mappingSeparator separates the source labels of a mapping rule from its target labels.
+const mappingSeparator = "="
+
+// Mapping structs are tables of rules that convert labels from one inventory into another, such as ARPAbet into IPA.
+// Rules can map one label to one label, one label to many labels (splitting the duration of the Annotation),
+// and many adjacent labels to one label (merging their annotations).
+type Mapping struct {
+	rules []mappingRule
+	name  string
+}
+
+// mappingRule is a single rule of a Mapping, with the relative duration of each target label.
+type mappingRule struct {
+	source  []string
+	targets []string
+	weights []float64
+}
+
+// GetName gets the name of a Mapping.
+func (mapping *Mapping) GetName() string {
+	return mapping.name
+}
+
+// SetName sets the name of a Mapping.
+func (mapping *Mapping) SetName(name string) {
+	mapping.name = name
+}
+
+// GetLength gets the total amount of rules in a Mapping.
+func (mapping *Mapping) GetLength() int {
+	return len(mapping.rules)
+}
+
+// AddRule adds a rule that maps a sequence of source labels to a sequence of target labels, replacing any rule with the same source.
+// Weights set the relative duration of each target label when an Annotation is split, and default to an even split.
+func (mapping *Mapping) AddRule(source []string, targets []string, weights ...float64) error {
+	if len(source) == 0 || len(targets) == 0 {
+		return fmt.Errorf("error: mapping rules need at least one source and one target label")
+	}
+
+	if len(weights) == 0 {
+		for range targets {
+			weights = append(weights, 1)
+		}
+	}
+	if len(weights) != len(targets) {
+		return fmt.Errorf("error: mapping rule for %v has %d weights for %d targets", source, len(weights), len(targets))
+	}
+	for _, weight := range weights {
+		if weight <= 0 {
+			return fmt.Errorf("error: mapping rule for %v has weight %s, weights must be positive", source, f2s(weight))
+		}
+	}
+
+	rule := mappingRule{source: source, targets: targets, weights: weights}
+
+	for i, existing := range mapping.rules {
+		if isEqualSlice(existing.source, source) {
+			mapping.rules[i] = rule
+			return nil
+		}
+	}
+
+	mapping.rules = append(mapping.rules, rule)
+	return nil
+}
+
+// ReadMapping takes a path to a mapping table and reads its contents into a Mapping. See ParseMapping for the accepted format.
+func ReadMapping(path string) (Mapping, error) {
+	mappingData, err := os.Open(path)
+	if err != nil {
+		return Mapping{}, err
+	}
+	defer func() {
+		closingError := mappingData.Close()
+		if err == nil {
+			err = closingError
+		}
+	}()
+
+	return ParseMapping(mappingData, filepath.Base(path))
+}
+
+// ParseMapping reads a mapping table from an io.Reader into a Mapping with the given name.
+// Each line holds one rule, with whitespace separated source and target labels on either side of a lone `=`.
+// A target label can end in `:weight` to set its relative duration. Empty lines and lines starting with `#` are ignored.
+//
+//	pau = sil
+//	ky = k:1 y:2
+//	k y = ky
+func ParseMapping(reader io.Reader, name string) (Mapping, error) {
+	mapping := Mapping{name: name}
+	sources := make(map[string]int)
+
+	line := bufio.NewScanner(reader)
+	lineNumber := 0
+
+	for line.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(line.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		separator := -1
+		for i, field := range fields {
+			if field == mappingSeparator {
+				separator = i
+				break
+			}
+		}
+		if separator == -1 {
+			return mapping, fmt.Errorf("error: malformed mapping %s at line %d: missing %q", name, lineNumber, mappingSeparator)
+		}
+
+		source := fields[:separator]
+		var targets []string
+		var weights []float64
+		for _, field := range fields[separator+1:] {
+			target, weight := splitWeight(field)
+			targets = append(targets, target)
+			weights = append(weights, weight)
+		}
+
+		// the same source twice is most likely a mistake in the table
+		key := strings.Join(source, " ")
+		if previous, exists := sources[key]; exists {
+			return mapping, fmt.Errorf("error: malformed mapping %s at line %d: %q is already mapped at line %d", name, lineNumber, key, previous)
+		}
+		sources[key] = lineNumber
+
+		err := mapping.AddRule(source, targets, weights...)
+		if err != nil {
+			return mapping, fmt.Errorf("error: malformed mapping %s at line %d: %v", name, lineNumber, err)
+		}
+	}
+	if err := line.Err(); err != nil {
+		return mapping, err
+	}
+
+	return mapping, nil
+}
+
+// Apply converts the labels of a Lab, including alternative transcriptions, using the rules of a Mapping.
+// At each Annotation the rule with the longest matching source is used. One-to-one rules keep the score, auxiliary labels and comment
+// of the Annotation, while rules that split or merge annotations create new annotations without them.
+// Labels without a matching rule are left unchanged, and returned in the order they were first found.
+func (mapping *Mapping) Apply(lab *Lab) []string {
+	var unmapped []string
+	found := make(map[string]bool)
+
+	// index the rules by their first source label, so only a few rules have to be compared per Annotation
+	index := make(map[string][]mappingRule)
+	for _, rule := range mapping.rules {
+		index[rule.source[0]] = append(index[rule.source[0]], rule)
+	}
+
+	apply := func(annotations []Annotation) []Annotation {
+		var result []Annotation
+
+		for i := 0; i < len(annotations); {
+			rule, matched := longestMatch(index[annotations[i].label], annotations[i:])
+			if !matched {
+				if !found[annotations[i].label] {
+					found[annotations[i].label] = true
+					unmapped = append(unmapped, annotations[i].label)
+				}
+
+				result = append(result, annotations[i])
+				i++
+				continue
+			}
+
+			result = append(result, rule.convert(annotations[i:i+len(rule.source)])...)
+			i += len(rule.source)
+		}
+
+		return result
+	}
+
+	lab.annotations = apply(lab.annotations)
+	lab.ends = nil
+	for i, alternative := range lab.alternatives {
+		lab.alternatives[i] = apply(alternative)
+	}
+
+	return unmapped
+}
+
+// convert maps the annotations matched by a rule to its targets, splitting their combined duration by the weights of the rule.
+func (rule *mappingRule) convert(matched []Annotation) []Annotation {
+	// one-to-one rules only change the label
+	if len(matched) == 1 && len(rule.targets) == 1 {
+		annotation := matched[0]
+		annotation.label = rule.targets[0]
+		return []Annotation{annotation}
+	}
+
+	start := matched[0].start
+	end := matched[len(matched)-1].end
+
+	totalWeight := 0.0
+	for _, weight := range rule.weights {
+		totalWeight += weight
+	}
+
+	var result []Annotation
+	cumulativeWeight := 0.0
+	for i, target := range rule.targets {
+		annotation := Annotation{label: target, line: matched[0].line, sampleRate: matched[0].sampleRate}
+		annotation.start = start + (end-start)*cumulativeWeight/totalWeight
+
+		cumulativeWeight += rule.weights[i]
+		annotation.end = start + (end-start)*cumulativeWeight/totalWeight
+
+		// the last target always ends exactly where the matched annotations ended
+		if i == len(rule.targets)-1 {
+			annotation.end = end
+		}
+
+		result = append(result, annotation)
+	}
+
+	return result
+}
+
+// longestMatch returns the rule with the longest source that matches the labels at the start of the annotations.
+func longestMatch(rules []mappingRule, annotations []Annotation) (mappingRule, bool) {
+	var best mappingRule
+	matched := false
+
+	for _, rule := range rules {
+		if len(rule.source) > len(annotations) || (matched && len(rule.source) <= len(best.source)) {
+			continue
+		}
+
+		matches := true
+		for i, label := range rule.source {
+			if annotations[i].label != label {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			best = rule
+			matched = true
+		}
+	}
+
+	return best, matched
+}
+
+// splitWeight splits a target label of a mapping table into its label and weight, which defaults to 1.
+func splitWeight(field string) (string, float64) {
+	separator := strings.LastIndex(field, ":")
+	if separator <= 0 {
+		return field, 1
+	}
+
+	weight, err := strconv.ParseFloat(field[separator+1:], 64)
+	if err != nil {
+		return field, 1
+	}
+
+	return field[:separator], weight
+}
@@ -0,0 +1,81 @@
+package htk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadingMapping(t *testing.T) {
+	mapping, err := ReadMapping("examples/mappings/test.map")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mapping.GetLength() != 5 {
+		t.Fatalf("wanted 5 rules, recieved %d", mapping.GetLength())
+	} else if mapping.GetName() != "test.map" {
+		t.Fatalf("wanted name test.map, recieved %s", mapping.GetName())
+	}
+
+	_, err = ParseMapping(strings.NewReader("a b\n"), "malformed")
+	if err == nil {
+		t.Fatal("wanted error for rule without separator")
+	}
+
+	_, err = ParseMapping(strings.NewReader("a = b\na = c\n"), "duplicate")
+	if err == nil {
+		t.Fatal("wanted error for duplicate rule")
+	}
+
+	_, err = ParseMapping(strings.NewReader("a = b:0\n"), "weight")
+	if err == nil {
+		t.Fatal("wanted error for zero weight")
+	}
+}
+
+func TestApplyingMapping(t *testing.T) {
+	mapping, err := ReadMapping("examples/mappings/test.map")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lab, err := ParseLab(strings.NewReader("0.0 1.0 pau -5.5\n1.0 2.0 ky\n2.0 2.5 c\n2.5 3.0 l\n3.0 3.5 a\n3.5 4.0 N\n4.0 4.5 sp\n4.5 5.0 c\n"), "test.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unmapped := mapping.Apply(&lab)
+
+	expected := "0.0 1.0 sil -5.5\n1.0 1.2 k\n1.2 2.0 y\n2.0 3.0 cl\n3.0 3.5 a\n3.5 4.0 N\n4.0 4.5 sil\n4.5 5.0 c\n"
+	if lab.ToString() != expected {
+		t.Fatalf("wanted %q, recieved %q", expected, lab.ToString())
+	}
+
+	if !isEqualSlice(unmapped, []string{"N", "c"}) {
+		t.Fatalf("wanted unmapped labels N and c, recieved %v", unmapped)
+	}
+}
+
+func TestAddingMappingRules(t *testing.T) {
+	mapping := Mapping{}
+
+	err := mapping.AddRule([]string{"a"}, []string{"b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mapping.AddRule([]string{"a"}, []string{"c", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mapping.GetLength() != 1 {
+		t.Fatalf("wanted rule to be replaced, recieved %d rules", mapping.GetLength())
+	}
+
+	if mapping.AddRule([]string{"a"}, []string{"b", "c"}, 1) == nil {
+		t.Fatal("wanted error for mismatched weights")
+	}
+	if mapping.AddRule(nil, []string{"b"}) == nil {
+		t.Fatal("wanted error for rule without source")
+	}
+}
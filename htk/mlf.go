@@ -0,0 +1,429 @@
+package htk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vocatart/golab/fileio"
+)
+
+// mlfHeader is the first line of every HTK Master Label File.
+const mlfHeader = "#!MLF!#"
+
+// SearchMode is the way an MLF entry finds the labels for a pattern.
+type SearchMode uint8
+
+const (
+	// Immediate entries store their labels inside the MLF itself.
+	Immediate SearchMode = iota
+	// SimpleSearch entries (`->`) look for a label file with the same base name inside a directory.
+	SimpleSearch
+	// FullSearch entries (`=>`) look for a label file with the full utterance path appended to a directory.
+	FullSearch
+)
+
+// MLF structs are HTK Master Label Files, which hold the labels of many utterances in a single file.
+// Each entry has a pattern, and either stores a Lab directly or refers to a directory containing label files.
+type MLF struct {
+	entries   []mlfEntry
+	name      string
+	directory string
+	options   []ReadOption
+}
+
+// mlfEntry is a single pattern of an MLF, with its labels or the directory its labels are found in.
+type mlfEntry struct {
+	pattern   string
+	mode      SearchMode
+	directory string
+	lab       Lab
+}
+
+// GetName gets the name of an MLF.
+func (mlf *MLF) GetName() string {
+	return mlf.name
+}
+
+// SetName sets the name of an MLF.
+func (mlf *MLF) SetName(name string) {
+	mlf.name = name
+}
+
+// GetPatterns gets every pattern of an MLF, in the order they appear in the file.
+func (mlf *MLF) GetPatterns() []string {
+	var patterns []string
+
+	for _, entry := range mlf.entries {
+		patterns = append(patterns, entry.pattern)
+	}
+
+	return patterns
+}
+
+// GetLabs gets every Lab stored inside an MLF, mapped by pattern. Entries that refer to a directory are not included.
+func (mlf *MLF) GetLabs() map[string]Lab {
+	labs := make(map[string]Lab)
+
+	for _, entry := range mlf.entries {
+		if entry.mode == Immediate {
+			labs[entry.pattern] = entry.lab
+		}
+	}
+
+	return labs
+}
+
+// GetLab gets the Lab stored for an exact pattern of an MLF. Returns false if no Lab is stored for the pattern.
+func (mlf *MLF) GetLab(pattern string) (Lab, bool) {
+	index := mlf.indexOf(pattern)
+	if index == -1 || mlf.entries[index].mode != Immediate {
+		return Lab{}, false
+	}
+
+	return mlf.entries[index].lab, true
+}
+
+// SetLab stores a Lab for a pattern of an MLF, replacing the existing entry if the pattern already exists.
+func (mlf *MLF) SetLab(pattern string, lab Lab) {
+	mlf.setEntry(mlfEntry{pattern: pattern, mode: Immediate, lab: lab})
+}
+
+// SetReference makes a pattern of an MLF refer to label files inside a directory, replacing the existing entry if the pattern already exists.
+func (mlf *MLF) SetReference(pattern string, mode SearchMode, directory string) error {
+	if mode != SimpleSearch && mode != FullSearch {
+		return fmt.Errorf("error: pattern %q must use SimpleSearch or FullSearch to refer to a directory", pattern)
+	}
+
+	mlf.setEntry(mlfEntry{pattern: pattern, mode: mode, directory: directory})
+	return nil
+}
+
+// GetReference gets the SearchMode and directory of a pattern of an MLF. Returns Immediate if the labels of the pattern are stored inside the MLF.
+func (mlf *MLF) GetReference(pattern string) (SearchMode, string) {
+	index := mlf.indexOf(pattern)
+	if index == -1 {
+		return Immediate, ""
+	}
+
+	return mlf.entries[index].mode, mlf.entries[index].directory
+}
+
+// RemoveEntry removes a pattern from an MLF, if it exists.
+func (mlf *MLF) RemoveEntry(pattern string) {
+	index := mlf.indexOf(pattern)
+	if index != -1 {
+		mlf.entries = append(mlf.entries[:index], mlf.entries[index+1:]...)
+	}
+}
+
+// GetLength gets the total amount of entries in an MLF.
+func (mlf *MLF) GetLength() int {
+	return len(mlf.entries)
+}
+
+// Lookup finds the Lab for an utterance path, using the first pattern of the MLF that matches it.
+// Patterns can use `*` to match any sequence of characters and `?` to match a single character.
+// Entries that refer to a directory read the label file from disk. Relative directories are resolved from the directory of the MLF.
+func (mlf *MLF) Lookup(utterance string) (Lab, error) {
+	utterance = strings.Replace(utterance, "\\", "/", -1)
+
+	for _, entry := range mlf.entries {
+		if !matchPattern(entry.pattern, utterance) {
+			continue
+		}
+
+		switch entry.mode {
+		case SimpleSearch:
+			return ReadLab(filepath.Join(mlf.resolveDirectory(entry.directory), filepath.Base(utterance)), mlf.options...)
+		case FullSearch:
+			return ReadLab(filepath.Join(mlf.resolveDirectory(entry.directory), utterance), mlf.options...)
+		default:
+			return entry.lab, nil
+		}
+	}
+
+	return Lab{}, fmt.Errorf("error: no pattern in mlf %s matches %s", mlf.name, utterance)
+}
+
+// ReadMLF takes a path to an HTK Master Label File and reads its contents into an MLF.
+// The ReadOption functions are applied to every Lab in the MLF, including ones read through Lookup.
+// Relative directories referred to by the MLF are resolved from the directory the MLF is in.
+func ReadMLF(path string, options ...ReadOption) (MLF, error) {
+	mlfData, err := os.Open(path)
+	if err != nil {
+		return MLF{}, err
+	}
+	defer func() {
+		closingError := mlfData.Close()
+		if err == nil {
+			err = closingError
+		}
+	}()
+
+	mlf, err := ParseMLF(mlfData, filepath.Base(path), options...)
+	mlf.directory = filepath.Dir(path)
+
+	return mlf, err
+}
+
+// ParseMLF reads the contents of an HTK Master Label File from an io.Reader into an MLF with the given name.
+// The ReadOption functions are applied to every Lab in the MLF, including ones read through Lookup.
+// Relative directories referred to by the MLF are resolved from the current working directory.
+func ParseMLF(reader io.Reader, name string, options ...ReadOption) (MLF, error) {
+	mlf := MLF{name: name, options: options}
+	config := newReadConfig(options)
+
+	decoded, _, err := decodeContent(reader, config)
+	if err != nil {
+		return mlf, fmt.Errorf("error: cannot read mlf %s: %v", mlf.name, err)
+	}
+
+	line := bufio.NewScanner(decoded)
+	lineNumber := 0
+
+	// the first line must be the MLF header
+	if !line.Scan() || strings.TrimSpace(line.Text()) != mlfHeader {
+		return mlf, fmt.Errorf("error: mlf %s is missing the %s header", mlf.name, mlfHeader)
+	}
+	lineNumber++
+
+	// the entry currently being read, and its label lines
+	var entry *mlfEntry
+	var entryLines []string
+	entryLine := 0
+
+	for line.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(line.Text())
+
+		if entry == nil {
+			// outside of an entry, only empty lines and patterns are allowed
+			if text == "" {
+				continue
+			}
+
+			newEntry, err := parseMLFPattern(text)
+			if err != nil {
+				return mlf, fmt.Errorf("error: malformed mlf %s at line %d: %v", mlf.name, lineNumber, err)
+			}
+
+			// entries that refer to a directory have no label lines
+			if newEntry.mode != Immediate {
+				mlf.entries = append(mlf.entries, newEntry)
+				continue
+			}
+
+			entry = &newEntry
+			entryLines = nil
+			entryLine = lineNumber + 1
+			continue
+		}
+
+		// a single period ends the labels of an entry
+		if text == "." {
+			lab, err := parseLabLines(entryLines, entryLine, config)
+			if err != nil {
+				err.Path = mlf.name
+				return mlf, fmt.Errorf("error: malformed mlf %s in entry %q: %w", mlf.name, entry.pattern, err)
+			}
+			lab.name = filepath.Base(entry.pattern)
+			entry.lab = lab
+
+			mlf.entries = append(mlf.entries, *entry)
+			entry = nil
+			continue
+		}
+
+		entryLines = append(entryLines, text)
+	}
+	if err := line.Err(); err != nil {
+		return mlf, err
+	}
+
+	if entry != nil {
+		return mlf, fmt.Errorf("error: malformed mlf %s: entry %q is missing its closing period", mlf.name, entry.pattern)
+	}
+
+	return mlf, nil
+}
+
+// WriteMLF writes an MLF to a file from a given path. If the file already exists, it will be overwritten only if overwrite is set to true.
+// Each Lab is written using its own TimeUnit and precision.
+func (mlf *MLF) WriteMLF(path string, overwrite ...bool) error {
+	// if no overwrite is specified, default to false
+	if len(overwrite) == 0 {
+		overwrite = append(overwrite, false)
+	}
+
+	return mlf.WriteMLFWith(path, fileio.WithOverwrite(overwrite[0]))
+}
+
+// WriteMLFWith writes an MLF to a file from a given path, following the given write options.
+func (mlf *MLF) WriteMLFWith(path string, options ...fileio.WriteOption) error {
+	// make sure every lab can be written before creating the file
+	if err := mlf.checkUnits(); err != nil {
+		return err
+	}
+
+	return fileio.WriteFile(path, func(writer io.Writer) error {
+		_, err := mlf.WriteTo(writer)
+		return err
+	}, options...)
+}
+
+// WriteTo writes an MLF to an io.Writer. Each Lab is written using its own TimeUnit and precision.
+// Returns the number of bytes written, implementing io.WriterTo.
+func (mlf *MLF) WriteTo(writer io.Writer) (int64, error) {
+	if err := mlf.checkUnits(); err != nil {
+		return 0, err
+	}
+
+	count, err := io.WriteString(writer, mlf.ToString())
+	return int64(count), err
+}
+
+// checkUnits returns an error if the times of any Lab stored inside an MLF cannot be written.
+func (mlf *MLF) checkUnits() error {
+	for _, entry := range mlf.entries {
+		if entry.mode != Immediate {
+			continue
+		}
+		if _, err := entry.lab.unitsPerSecond(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ToString converts an MLF to a string.
+func (mlf *MLF) ToString() string {
+	result := mlfHeader + "\n"
+
+	for _, entry := range mlf.entries {
+		switch entry.mode {
+		case SimpleSearch:
+			result += fmt.Sprintf("\"%s\" -> %s\n", entry.pattern, entry.directory)
+		case FullSearch:
+			result += fmt.Sprintf("\"%s\" => %s\n", entry.pattern, entry.directory)
+		default:
+			result += fmt.Sprintf("\"%s\"\n", entry.pattern)
+			for _, line := range entry.lab.formatLines() {
+				result += line + "\n"
+			}
+			result += ".\n"
+		}
+	}
+
+	return result
+}
+
+// indexOf returns the index of the entry with an exact pattern in an MLF, or -1 if it does not exist.
+func (mlf *MLF) indexOf(pattern string) int {
+	for i, entry := range mlf.entries {
+		if entry.pattern == pattern {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// setEntry replaces the entry with the same pattern in an MLF, or appends it if the pattern does not exist yet.
+func (mlf *MLF) setEntry(entry mlfEntry) {
+	index := mlf.indexOf(entry.pattern)
+	if index == -1 {
+		mlf.entries = append(mlf.entries, entry)
+		return
+	}
+
+	mlf.entries[index] = entry
+}
+
+// resolveDirectory resolves a directory referred to by an MLF entry relative to the directory of the MLF.
+func (mlf *MLF) resolveDirectory(directory string) string {
+	if filepath.IsAbs(directory) || mlf.directory == "" {
+		return directory
+	}
+
+	return filepath.Join(mlf.directory, directory)
+}
+
+// parseMLFPattern converts a pattern line of an MLF into an entry, including a directory reference if there is one.
+func parseMLFPattern(text string) (mlfEntry, error) {
+	entry := mlfEntry{mode: Immediate}
+
+	// the pattern is usually quoted, but HTK also accepts it without quotes
+	var rest string
+	if strings.HasPrefix(text, "\"") {
+		closingQuote := strings.Index(text[1:], "\"")
+		if closingQuote == -1 {
+			return entry, fmt.Errorf("unterminated pattern %s", text)
+		}
+		entry.pattern = text[1 : closingQuote+1]
+		rest = strings.TrimSpace(text[closingQuote+2:])
+	} else {
+		fields := strings.Fields(text)
+		entry.pattern = fields[0]
+		rest = strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+	}
+
+	if entry.pattern == "" {
+		return entry, fmt.Errorf("empty pattern")
+	}
+
+	switch {
+	case rest == "":
+		return entry, nil
+	case strings.HasPrefix(rest, "->"):
+		entry.mode = SimpleSearch
+		entry.directory = strings.TrimSpace(rest[2:])
+	case strings.HasPrefix(rest, "=>"):
+		entry.mode = FullSearch
+		entry.directory = strings.TrimSpace(rest[2:])
+	default:
+		return entry, fmt.Errorf("unexpected %q after pattern %q", rest, entry.pattern)
+	}
+
+	if entry.directory == "" {
+		return entry, fmt.Errorf("pattern %q is missing a directory", entry.pattern)
+	}
+
+	return entry, nil
+}
+
+// matchPattern returns true if a name matches an HTK pattern.
+// `*` matches any sequence of characters, including path separators, and `?` matches any single character.
+func matchPattern(pattern string, name string) bool {
+	// index of the last star in the pattern, and the position in name it is currently matching up to
+	starIndex, starMatch := -1, 0
+	patternIndex, nameIndex := 0, 0
+
+	for nameIndex < len(name) {
+		switch {
+		case patternIndex < len(pattern) && (pattern[patternIndex] == '?' || pattern[patternIndex] == name[nameIndex]):
+			patternIndex++
+			nameIndex++
+		case patternIndex < len(pattern) && pattern[patternIndex] == '*':
+			starIndex, starMatch = patternIndex, nameIndex
+			patternIndex++
+		case starIndex != -1:
+			// let the last star match one more character and try again
+			starMatch++
+			patternIndex, nameIndex = starIndex+1, starMatch
+		default:
+			return false
+		}
+	}
+
+	// any stars left at the end of the pattern can match nothing
+	for patternIndex < len(pattern) && pattern[patternIndex] == '*' {
+		patternIndex++
+	}
+
+	return patternIndex == len(pattern)
+}
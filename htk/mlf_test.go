@@ -0,0 +1,141 @@
+package htk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadingMLF(t *testing.T) {
+	mlf, err := ReadMLF("examples/mlf/test.mlf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mlf.GetLength() != 4 {
+		t.Fatalf("wanted 4 entries, recieved %d", mlf.GetLength())
+	} else if len(mlf.GetLabs()) != 2 {
+		t.Fatalf("wanted 2 labs, recieved %d", len(mlf.GetLabs()))
+	}
+
+	lab, ok := mlf.GetLab("*/a01.lab")
+	if !ok {
+		t.Fatal("wanted lab for pattern */a01.lab")
+	} else if lab.GetTimeUnit() != HTKUnits || lab.GetAnnotations()[1].GetStart() != 0.25 {
+		t.Fatalf("wanted htk times, recieved %s with start %f", lab.GetTimeUnit(), lab.GetAnnotations()[1].GetStart())
+	} else if lab.GetName() != "a01.lab" {
+		t.Fatalf("wanted name a01.lab, recieved %s", lab.GetName())
+	}
+
+	mode, directory := mlf.GetReference("*/d*.lab")
+	if mode != FullSearch || directory != "labs" {
+		t.Fatalf("wanted full search in labs, recieved %d in %s", mode, directory)
+	}
+}
+
+func TestLookingUpMLF(t *testing.T) {
+	mlf, err := ReadMLF("examples/mlf/test.mlf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lab, err := mlf.Lookup("corpus/b01.lab")
+	if err != nil {
+		t.Fatal(err)
+	} else if !isEqualSlice(lab.GetLabels(), []string{"sil", "hello", "sil"}) {
+		t.Fatalf("wanted labels of */b??.lab, recieved %v", lab.GetLabels())
+	}
+
+	lab, err = mlf.Lookup("corpus/c01.lab")
+	if err != nil {
+		t.Fatal(err)
+	} else if !isEqualSlice(lab.GetLabels(), []string{"test", "test2"}) {
+		t.Fatalf("wanted labels of labs/c01.lab, recieved %v", lab.GetLabels())
+	}
+
+	lab, err = mlf.Lookup("data/d01.lab")
+	if err != nil {
+		t.Fatal(err)
+	} else if !isEqualSlice(lab.GetLabels(), []string{"test"}) {
+		t.Fatalf("wanted labels of labs/data/d01.lab, recieved %v", lab.GetLabels())
+	}
+
+	_, err = mlf.Lookup("corpus/b001.lab")
+	if err == nil {
+		t.Fatal("wanted error for utterance without matching pattern")
+	}
+}
+
+func TestWritingMLF(t *testing.T) {
+	mlf, err := ReadMLF("examples/mlf/test.mlf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lab, err := ReadLab("examples/one_line.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mlf.SetLab("*/e01.lab", lab)
+	mlf.RemoveEntry("*/b??.lab")
+
+	expected := "#!MLF!#\n\"*/a01.lab\"\n0 2500000 sil\n2500000 5000000 a\n5000000 7500000 sil\n.\n\"*/c*.lab\" -> labs\n\"*/d*.lab\" => labs\n\"*/e01.lab\"\n0.0000000 10.0000000 test\n.\n"
+	if mlf.ToString() != expected {
+		t.Fatalf("malformed mlf string, recieved:\n%s", mlf.ToString())
+	}
+
+	err = mlf.WriteMLF("examples/mlf/output.mlf", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := ReadMLF("examples/mlf/output.mlf")
+	if err != nil {
+		t.Fatal(err)
+	} else if written.ToString() != expected {
+		t.Fatalf("mlf did not round-trip, recieved:\n%s", written.ToString())
+	}
+}
+
+func TestMatchingPatterns(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		matches bool
+	}{
+		{"*/a.lab", "data/speaker/a.lab", true},
+		{"*/a.lab", "data/ba.lab", false},
+		{"*", "anything", true},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"*b*", "abc", true},
+	}
+
+	for _, c := range cases {
+		if matchPattern(c.pattern, c.name) != c.matches {
+			t.Errorf("pattern %q against %q: wanted %v", c.pattern, c.name, c.matches)
+		}
+	}
+}
+
+func TestParsingMLFFromReader(t *testing.T) {
+	mlf, err := ParseMLF(strings.NewReader("#!MLF!#\n\"*/a.lab\"\nsil\na\n.\n"), "reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	_, err = mlf.WriteTo(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buffer.String() != "#!MLF!#\n\"*/a.lab\"\n0.0000000 0.0000000 sil\n0.0000000 0.0000000 a\n.\n" {
+		t.Fatalf("malformed mlf string, recieved:\n%s", buffer.String())
+	}
+
+	_, err = ParseMLF(strings.NewReader("#!MLF!#\n\"*/a.lab\"\nsil\n"), "unterminated")
+	if err == nil {
+		t.Fatal("wanted error for entry without closing period")
+	}
+}
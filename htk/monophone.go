@@ -0,0 +1,285 @@
+package htk
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// MismatchKind is the type of difference found between a monophone Lab and a full-context Lab.
+type MismatchKind uint8
+
+const (
+	// PhonemeMismatch is an Annotation whose monophone label differs from the phoneme of its full-context label.
+	PhonemeMismatch MismatchKind = iota
+	// StartMismatch is an Annotation that starts at a different time in the two labs.
+	StartMismatch
+	// EndMismatch is an Annotation that ends at a different time in the two labs.
+	EndMismatch
+	// MissingInMono is a full-context Annotation without a monophone Annotation at the same index.
+	MissingInMono
+	// MissingInFull is a monophone Annotation without a full-context Annotation at the same index.
+	MissingInFull
+	// MissingMonoLab is a full-context Lab without a monophone Lab of the same name.
+	MissingMonoLab
+	// MissingFullLab is a monophone Lab without a full-context Lab of the same name.
+	MissingFullLab
+	// LabError is a pair of labs that could not be read or compared, with the error in the Err of the Mismatch.
+	LabError
+)
+
+// String returns the name of a MismatchKind.
+func (kind MismatchKind) String() string {
+	switch kind {
+	case PhonemeMismatch:
+		return "phoneme mismatch"
+	case StartMismatch:
+		return "start mismatch"
+	case EndMismatch:
+		return "end mismatch"
+	case MissingInMono:
+		return "missing in mono"
+	case MissingInFull:
+		return "missing in full"
+	case MissingMonoLab:
+		return "missing mono lab"
+	case MissingFullLab:
+		return "missing full lab"
+	case LabError:
+		return "lab error"
+	default:
+		return fmt.Sprintf("MismatchKind(%d)", uint8(kind))
+	}
+}
+
+// Mismatch is a single difference found by CompareMonophone.
+// Mono and Full are the phonemes at Index, and MonoTime and FullTime the compared boundary for start and end mismatches.
+// Err is only set for a LabError.
+type Mismatch struct {
+	Kind     MismatchKind
+	Index    int
+	Mono     string
+	Full     string
+	MonoTime float64
+	FullTime float64
+	Err      error
+}
+
+// String returns a readable description of a Mismatch.
+func (mismatch Mismatch) String() string {
+	switch mismatch.Kind {
+	case StartMismatch, EndMismatch:
+		return fmt.Sprintf("%s at annotation %d (%s): mono %s, full %s", mismatch.Kind, mismatch.Index, mismatch.Mono, f2s(mismatch.MonoTime), f2s(mismatch.FullTime))
+	case MissingMonoLab, MissingFullLab:
+		return mismatch.Kind.String()
+	case LabError:
+		return fmt.Sprintf("%s: %v", mismatch.Kind, mismatch.Err)
+	default:
+		return fmt.Sprintf("%s at annotation %d: mono %q, full %q", mismatch.Kind, mismatch.Index, mismatch.Mono, mismatch.Full)
+	}
+}
+
+// ToMonophone creates a monophone Lab from a full-context Lab, using the phoneme field of a ContextTemplate as the label.
+// Another field of the template can be given to extract it instead. Only times and labels are kept, and alternative transcriptions are dropped.
+func (lab *Lab) ToMonophone(template ContextTemplate, field ...string) (Lab, error) {
+	// if no field is specified, default to the phoneme field of the template
+	if len(field) == 0 {
+		field = append(field, template.phoneme)
+	}
+	if _, exists := template.indices[field[0]]; !exists {
+		return Lab{}, fmt.Errorf("error: context template %s has no field %s", template.name, field[0])
+	}
+
+	contexts, err := lab.GetFullContexts(template)
+	if err != nil {
+		return Lab{}, err
+	}
+
+	return lab.withLabels(func(i int) (string, error) {
+		value, _ := contexts[i].Get(field[0])
+		return value, nil
+	})
+}
+
+// ToMonophoneMatching creates a monophone Lab from a full-context Lab, using the first submatch of a regular expression as the label,
+// such as `-(.+?)\+` for HTS English labels. If the expression has no submatches, the whole match is used.
+// Only times and labels are kept, and alternative transcriptions are dropped.
+func (lab *Lab) ToMonophoneMatching(pattern *regexp.Regexp) (Lab, error) {
+	return lab.withLabels(func(i int) (string, error) {
+		match := pattern.FindStringSubmatch(lab.annotations[i].label)
+		if match == nil {
+			return "", fmt.Errorf("error: annotation %d of lab %s (line %d): label %q does not match %s", i, lab.name, lab.annotations[i].line, lab.annotations[i].label, pattern)
+		}
+
+		if len(match) > 1 {
+			return match[1], nil
+		}
+		return match[0], nil
+	})
+}
+
+// withLabels returns a Lab with the times of a Lab and new labels, leaving out scores, auxiliary labels, comments and alternatives.
+func (lab *Lab) withLabels(label func(i int) (string, error)) (Lab, error) {
+	result := Lab{name: lab.name, precision: lab.precision, unit: lab.unit, sampleRate: lab.sampleRate, encoding: lab.encoding}
+
+	for i, annotation := range lab.annotations {
+		value, err := label(i)
+		if err != nil {
+			return Lab{}, err
+		}
+
+		result.annotations = append(result.annotations, Annotation{
+			start:      annotation.start,
+			end:        annotation.end,
+			label:      value,
+			line:       annotation.line,
+			sampleRate: annotation.sampleRate,
+		})
+	}
+
+	return result, nil
+}
+
+// CompareMonophone checks that a monophone Lab and a full-context Lab agree on the phoneme and boundaries of every Annotation.
+// Annotations are compared by index, and differences in time up to the tolerance are ignored, which defaults to 0.
+// Returns nil if the labs agree.
+func CompareMonophone(mono Lab, full Lab, template ContextTemplate, tolerance ...float64) ([]Mismatch, error) {
+	// if no tolerance is specified, default to 0
+	if len(tolerance) == 0 {
+		tolerance = append(tolerance, 0)
+	}
+	epsilon := math.Abs(tolerance[0]) + toleranceEpsilon
+
+	contexts, err := full.GetFullContexts(template)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []Mismatch
+
+	for i := 0; i < max(len(mono.annotations), len(full.annotations)); i++ {
+		switch {
+		case i >= len(mono.annotations):
+			mismatches = append(mismatches, Mismatch{Kind: MissingInMono, Index: i, Full: contexts[i].GetPhoneme()})
+			continue
+		case i >= len(full.annotations):
+			mismatches = append(mismatches, Mismatch{Kind: MissingInFull, Index: i, Mono: mono.annotations[i].label})
+			continue
+		}
+
+		monoAnnotation := mono.annotations[i]
+		fullAnnotation := full.annotations[i]
+		mismatch := Mismatch{Index: i, Mono: monoAnnotation.label, Full: contexts[i].GetPhoneme()}
+
+		if mismatch.Mono != mismatch.Full {
+			mismatch.Kind = PhonemeMismatch
+			mismatches = append(mismatches, mismatch)
+		}
+
+		if math.Abs(monoAnnotation.start-fullAnnotation.start) > epsilon {
+			mismatch.Kind = StartMismatch
+			mismatch.MonoTime, mismatch.FullTime = monoAnnotation.start, fullAnnotation.start
+			mismatches = append(mismatches, mismatch)
+		}
+
+		if math.Abs(monoAnnotation.end-fullAnnotation.end) > epsilon {
+			mismatch.Kind = EndMismatch
+			mismatch.MonoTime, mismatch.FullTime = monoAnnotation.end, fullAnnotation.end
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+
+	return mismatches, nil
+}
+
+// CompareMonophoneDirectories runs CompareMonophone on every .lab file of a full-context directory and the file of the same name
+// in a monophone directory, such as the `full` and `mono` directories of an NNSVS dataset.
+// Returns the mismatches of each file name that has any. A full-context Lab without a monophone Lab is reported as MissingMonoLab,
+// a monophone Lab without a full-context Lab as MissingFullLab, and a pair that cannot be read or compared as a LabError,
+// so a single broken file does not stop the comparison. Returns an error if either directory cannot be read.
+func CompareMonophoneDirectories(monoDirectory string, fullDirectory string, template ContextTemplate, tolerance ...float64) (map[string][]Mismatch, error) {
+	fullNames, err := labNames(fullDirectory)
+	if err != nil {
+		return nil, err
+	}
+	monoNames, err := labNames(monoDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]Mismatch)
+
+	for name := range monoNames {
+		if !fullNames[name] {
+			results[name] = []Mismatch{{Kind: MissingFullLab, Index: -1}}
+		}
+	}
+
+	for name := range fullNames {
+		if !monoNames[name] {
+			results[name] = []Mismatch{{Kind: MissingMonoLab, Index: -1}}
+			continue
+		}
+
+		mismatches, err := compareMonophoneFiles(filepath.Join(monoDirectory, name), filepath.Join(fullDirectory, name), template, tolerance...)
+		if err != nil {
+			results[name] = []Mismatch{{Kind: LabError, Index: -1, Err: err}}
+			continue
+		}
+		if len(mismatches) > 0 {
+			results[name] = mismatches
+		}
+	}
+
+	return results, nil
+}
+
+// compareMonophoneFiles reads a monophone and a full-context Lab and runs CompareMonophone on them.
+func compareMonophoneFiles(monoPath string, fullPath string, template ContextTemplate, tolerance ...float64) ([]Mismatch, error) {
+	full, err := ReadLab(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	mono, err := ReadLab(monoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return CompareMonophone(mono, full, template, tolerance...)
+}
+
+// labNames returns the file names of every .lab file in a directory. Returns an error if the directory cannot be read.
+func labNames(directory string) (map[string]bool, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".lab" {
+			names[entry.Name()] = true
+		}
+	}
+
+	return names, nil
+}
+
+// CopyTimings sets the boundaries of every Annotation of a Lab to those of another Lab with the same number of annotations,
+// such as moving a hand-corrected monophone Lab back onto its full-context Lab.
+func (lab *Lab) CopyTimings(source Lab) error {
+	if len(source.annotations) != len(lab.annotations) {
+		return fmt.Errorf("error: cannot copy timings of lab %s with %d annotations to lab %s with %d annotations",
+			source.name, len(source.annotations), lab.name, len(lab.annotations))
+	}
+
+	for i := range lab.annotations {
+		lab.annotations[i].start = source.annotations[i].start
+		lab.annotations[i].end = source.annotations[i].end
+	}
+	lab.ends = nil
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package htk
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestToMonophone(t *testing.T) {
+	full, err := ReadLab("examples/fullcontext/hts_english.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mono, err := full.ToMonophone(HTSEnglishTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isEqualSlice(mono.GetLabels(), []string{"pau", "ao", "th"}) {
+		t.Fatalf("wanted labels [pau ao th], recieved %v", mono.GetLabels())
+	}
+	if mono.GetAnnotations()[1].GetEnd() != full.GetAnnotations()[1].GetEnd() {
+		t.Fatalf("wanted end %f, recieved %f", full.GetAnnotations()[1].GetEnd(), mono.GetAnnotations()[1].GetEnd())
+	}
+	if mono.GetTimeUnit() != HTKUnits {
+		t.Fatalf("wanted time unit htk, recieved %s", mono.GetTimeUnit())
+	}
+
+	next, err := full.ToMonophone(HTSEnglishTemplate, "p4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.GetLabels()[0] != "ao" {
+		t.Fatalf("wanted next phoneme ao, recieved %s", next.GetLabels()[0])
+	}
+
+	_, err = full.ToMonophone(HTSEnglishTemplate, "z9")
+	if err == nil {
+		t.Fatal("wanted error for unknown field")
+	}
+
+	matched, err := full.ToMonophoneMatching(regexp.MustCompile(`-(.+?)\+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEqualSlice(matched.GetLabels(), mono.GetLabels()) {
+		t.Fatalf("wanted labels %v, recieved %v", mono.GetLabels(), matched.GetLabels())
+	}
+
+	_, err = full.ToMonophoneMatching(regexp.MustCompile(`^nothing$`))
+	if err == nil {
+		t.Fatal("wanted error for label that does not match")
+	}
+}
+
+func TestCompareMonophone(t *testing.T) {
+	full, err := ReadLab("examples/sync/full/02.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mono, err := ReadLab("examples/sync/mono/02.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mismatches, err := CompareMonophone(mono, full, HTSEnglishTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Mismatch{
+		{Kind: PhonemeMismatch, Index: 1, Mono: "aa", Full: "ao"},
+		{Kind: EndMismatch, Index: 1, Mono: "aa", Full: "ao", MonoTime: 0.32, FullTime: 0.315},
+		{Kind: StartMismatch, Index: 2, Mono: "th", Full: "th", MonoTime: 0.32, FullTime: 0.315},
+	}
+	if len(mismatches) != len(expected) {
+		t.Fatalf("wanted %d mismatches, recieved %d: %v", len(expected), len(mismatches), mismatches)
+	}
+	for i, mismatch := range mismatches {
+		if mismatch != expected[i] {
+			t.Errorf("wanted mismatch %v, recieved %v", expected[i], mismatch)
+		}
+	}
+
+	if mismatches[1].String() != "end mismatch at annotation 1 (aa): mono 0.32, full 0.315" {
+		t.Fatalf("malformed mismatch string, recieved %q", mismatches[1].String())
+	}
+
+	// a tolerance of 5ms hides the boundary mismatches
+	mismatches, err = CompareMonophone(mono, full, HTSEnglishTemplate, 0.005)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 1 {
+		t.Fatalf("wanted 1 mismatch, recieved %v", mismatches)
+	}
+
+	mono.SetAnnotations(mono.GetAnnotations()[:2])
+	mismatches, _ = CompareMonophone(mono, full, HTSEnglishTemplate, 0.005)
+	if len(mismatches) != 2 || mismatches[1].Kind != MissingInMono {
+		t.Fatalf("wanted a missing annotation, recieved %v", mismatches)
+	}
+}
+
+func TestCompareMonophoneDirectories(t *testing.T) {
+	results, err := CompareMonophoneDirectories("examples/sync/mono", "examples/sync/full", HTSEnglishTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("wanted 2 labs with mismatches, recieved %v", results)
+	}
+	if _, exists := results["01.lab"]; exists {
+		t.Fatal("wanted no mismatches for 01.lab")
+	}
+	if len(results["02.lab"]) != 3 {
+		t.Fatalf("wanted 3 mismatches for 02.lab, recieved %v", results["02.lab"])
+	}
+	if len(results["03.lab"]) != 1 || results["03.lab"][0].Kind != MissingMonoLab {
+		t.Fatalf("wanted missing mono lab for 03.lab, recieved %v", results["03.lab"])
+	}
+}
+
+func TestCompareMonophoneDirectoriesUnmatched(t *testing.T) {
+	mono := filepath.Join(t.TempDir(), "mono")
+	full := filepath.Join(t.TempDir(), "full")
+
+	files := map[string]string{
+		filepath.Join(mono, "01.lab"): "examples/sync/mono/01.lab",
+		filepath.Join(full, "01.lab"): "examples/sync/full/01.lab",
+		filepath.Join(mono, "04.lab"): "examples/sync/mono/01.lab",
+		filepath.Join(full, "05.lab"): "examples/sync/full/01.lab",
+	}
+	for path, source := range files {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, content, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the monophone lab of 05.lab is missing a label name
+	err := os.WriteFile(filepath.Join(mono, "05.lab"), []byte("0 1 a\n1 2\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := CompareMonophoneDirectories(mono, full, HTSEnglishTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("wanted 2 labs with mismatches, recieved %v", results)
+	}
+	if len(results["04.lab"]) != 1 || results["04.lab"][0].Kind != MissingFullLab {
+		t.Fatalf("wanted missing full lab for 04.lab, recieved %v", results["04.lab"])
+	}
+	if len(results["05.lab"]) != 1 || results["05.lab"][0].Kind != LabError || results["05.lab"][0].Err == nil {
+		t.Fatalf("wanted lab error for 05.lab, recieved %v", results["05.lab"])
+	}
+
+	_, err = CompareMonophoneDirectories(filepath.Join(mono, "missing"), full, HTSEnglishTemplate)
+	if err == nil {
+		t.Fatal("wanted error for missing monophone directory")
+	}
+}
+
+func TestCopyTimings(t *testing.T) {
+	full, err := ReadLab("examples/sync/full/02.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mono, err := ReadLab("examples/sync/mono/02.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = full.CopyTimings(mono)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full.GetAnnotations()[2].GetStart() != 0.32 {
+		t.Fatalf("wanted start 0.32, recieved %f", full.GetAnnotations()[2].GetStart())
+	}
+
+	mono.SetAnnotations(mono.GetAnnotations()[:1])
+	if full.CopyTimings(mono) == nil {
+		t.Fatal("wanted error for different annotation counts")
+	}
+}
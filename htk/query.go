@@ -0,0 +1,144 @@
+package htk
+
+import (
+	"fmt"
+	"sort"
+)
+
+// OverlapMode is how AnnotationsBetween and IndicesBetween decide whether an Annotation belongs to a range of time.
+type OverlapMode uint8
+
+const (
+	// Contained selects annotations that start and end inside the range.
+	Contained OverlapMode = iota
+	// Overlapping selects annotations that cover any part of the range.
+	Overlapping
+	// StartingIn selects annotations that start inside the range, wherever they end.
+	StartingIn
+)
+
+// String returns the name of an OverlapMode.
+func (mode OverlapMode) String() string {
+	switch mode {
+	case Contained:
+		return "contained"
+	case Overlapping:
+		return "overlapping"
+	case StartingIn:
+		return "starting in"
+	default:
+		return fmt.Sprintf("OverlapMode(%d)", uint8(mode))
+	}
+}
+
+// AnnotationAt gets the Annotation of a Lab that covers a time in seconds. Returns false if no Annotation covers it.
+// See IndexAt for how boundaries are handled.
+func (lab *Lab) AnnotationAt(time float64) (Annotation, bool) {
+	index := lab.IndexAt(time)
+	if index == -1 {
+		return Annotation{}, false
+	}
+
+	return lab.annotations[index], true
+}
+
+// IndexAt gets the index of the Annotation of a Lab that covers a time in seconds. Returns -1 if no Annotation covers it.
+// Annotations cover the time from their start up to but not including their end, so a boundary belongs to the Annotation starting at it.
+// Annotations are expected to be sorted by start time, as checked by Validate. If annotations overlap, the last one starting before the time is returned.
+// The latest end times are cached on the Lab, so annotations changed through the slice from GetAnnotations should be set again with SetAnnotations.
+func (lab *Lab) IndexAt(time float64) int {
+	// the first annotation starting after the time
+	after := sort.Search(len(lab.annotations), func(i int) bool {
+		return lab.annotations[i].start > time
+	})
+
+	return lab.coveringIndex(time, after)
+}
+
+// coveringIndex returns the index of the last Annotation before after that covers a time, or -1 if none covers it.
+// After must be the index of the first Annotation starting after the time.
+func (lab *Lab) coveringIndex(time float64, after int) int {
+	// earlier annotations can only cover the time while the latest end among them is after it
+	latestEnd := lab.latestEnds()
+	for i := after - 1; i >= 0 && latestEnd[i] > time; i-- {
+		if time < lab.annotations[i].end {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// latestEnds returns, for each Annotation of a Lab, the latest end of that Annotation and every one before it.
+// An Annotation starting earlier can be long enough to cover times after annotations that start later.
+// The result is kept until the annotations of the Lab change, so lookups do not have to go through every Annotation again.
+func (lab *Lab) latestEnds() []float64 {
+	if lab.ends != nil && len(lab.ends) == len(lab.annotations) {
+		return lab.ends
+	}
+
+	result := make([]float64, len(lab.annotations))
+	for i, annotation := range lab.annotations {
+		result[i] = annotation.end
+		if i > 0 && result[i-1] > result[i] {
+			result[i] = result[i-1]
+		}
+	}
+	lab.ends = result
+
+	return result
+}
+
+// AnnotationsBetween gets the annotations of a Lab in the range from start to end in seconds, selected by an OverlapMode.
+func (lab *Lab) AnnotationsBetween(start float64, end float64, mode OverlapMode) []Annotation {
+	var result []Annotation
+	for _, index := range lab.IndicesBetween(start, end, mode) {
+		result = append(result, lab.annotations[index])
+	}
+
+	return result
+}
+
+// IndicesBetween gets the indices of the annotations of a Lab in the range from start to end in seconds, selected by an OverlapMode.
+// Annotations are expected to be sorted by start time, as checked by Validate. Returns nil if end is before start.
+func (lab *Lab) IndicesBetween(start float64, end float64, mode OverlapMode) []int {
+	if end < start {
+		return nil
+	}
+
+	// annotations starting from first up to but not including last start inside the range
+	first := sort.Search(len(lab.annotations), func(i int) bool {
+		return lab.annotations[i].start >= start
+	})
+	last := sort.Search(len(lab.annotations), func(i int) bool {
+		return lab.annotations[i].start >= end
+	})
+
+	// annotations starting before the range can still overlap it
+	if mode == Overlapping {
+		latestEnd := lab.latestEnds()
+		for first > 0 && latestEnd[first-1] > start {
+			first--
+		}
+	}
+
+	var indices []int
+	for i := first; i < last; i++ {
+		annotation := lab.annotations[i]
+
+		switch mode {
+		case Contained:
+			if annotation.end > end {
+				continue
+			}
+		case Overlapping:
+			if annotation.end <= start {
+				continue
+			}
+		}
+
+		indices = append(indices, i)
+	}
+
+	return indices
+}
@@ -0,0 +1,166 @@
+package htk
+
+import (
+	"fmt"
+	"testing"
+)
+
+func queryLab() Lab {
+	lab := Lab{}
+	lab.SetAnnotations([]Annotation{
+		{start: 0, end: 1, label: "a"},
+		{start: 1, end: 2, label: "b"},
+		{start: 2.5, end: 3, label: "c"},
+		{start: 3, end: 4, label: "d"},
+	})
+
+	return lab
+}
+
+func TestAnnotationAt(t *testing.T) {
+	lab := queryLab()
+
+	tests := []struct {
+		time  float64
+		index int
+	}{
+		{0, 0},
+		{0.5, 0},
+		{1, 1},
+		{2.2, -1},
+		{2.5, 2},
+		{3.999, 3},
+		{4, -1},
+		{-1, -1},
+	}
+
+	for _, test := range tests {
+		if index := lab.IndexAt(test.time); index != test.index {
+			t.Errorf("wanted index %d at %v, recieved %d", test.index, test.time, index)
+		}
+	}
+
+	annotation, ok := lab.AnnotationAt(1.5)
+	if !ok || annotation.GetLabel() != "b" {
+		t.Fatalf("wanted annotation b, recieved %q", annotation.GetLabel())
+	}
+
+	_, ok = lab.AnnotationAt(2.2)
+	if ok {
+		t.Fatal("wanted no annotation inside gap")
+	}
+
+	empty := Lab{}
+	if empty.IndexAt(0) != -1 {
+		t.Fatal("wanted no annotation in empty lab")
+	}
+}
+
+func TestIndexAtOverlapping(t *testing.T) {
+	lab := Lab{}
+	lab.SetAnnotations([]Annotation{
+		{start: 0, end: 5, label: "long"},
+		{start: 1, end: 2, label: "short"},
+	})
+
+	if index := lab.IndexAt(3); index != 0 {
+		t.Fatalf("wanted index 0, recieved %d", index)
+	}
+	if index := lab.IndexAt(1.5); index != 1 {
+		t.Fatalf("wanted index 1, recieved %d", index)
+	}
+
+	// the long annotation covers times after annotations that do not overlap each other
+	lab.SetAnnotations([]Annotation{
+		{start: 0, end: 5, label: "long"},
+		{start: 1, end: 2, label: "a"},
+		{start: 2, end: 3, label: "b"},
+		{start: 3.5, end: 4, label: "c"},
+	})
+
+	if index := lab.IndexAt(3.2); index != 0 {
+		t.Fatalf("wanted index 0, recieved %d", index)
+	}
+
+	indices := lab.IndicesBetween(3.1, 3.3, Overlapping)
+	if len(indices) != 1 || indices[0] != 0 {
+		t.Fatalf("wanted indices [0], recieved %v", indices)
+	}
+
+	// the cached end times follow changes to the annotations
+	err := lab.Crop(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index := lab.IndexAt(3.2); index != -1 {
+		t.Fatalf("wanted index -1 after cropping, recieved %d", index)
+	}
+}
+
+func TestIndicesBetween(t *testing.T) {
+	lab := queryLab()
+
+	tests := []struct {
+		start    float64
+		end      float64
+		mode     OverlapMode
+		expected []int
+	}{
+		{0.5, 3.5, Contained, []int{1, 2}},
+		{0.5, 3.5, Overlapping, []int{0, 1, 2, 3}},
+		{0.5, 3.5, StartingIn, []int{1, 2, 3}},
+		{1, 2, Contained, []int{1}},
+		{1, 2, Overlapping, []int{1}},
+		{2.1, 2.4, Overlapping, nil},
+		{0, 4, Contained, []int{0, 1, 2, 3}},
+		{3, 1, Overlapping, nil},
+	}
+
+	for _, test := range tests {
+		indices := lab.IndicesBetween(test.start, test.end, test.mode)
+		if len(indices) != len(test.expected) {
+			t.Errorf("wanted %v for %s [%v, %v], recieved %v", test.expected, test.mode, test.start, test.end, indices)
+			continue
+		}
+
+		for i := range indices {
+			if indices[i] != test.expected[i] {
+				t.Errorf("wanted %v for %s [%v, %v], recieved %v", test.expected, test.mode, test.start, test.end, indices)
+				break
+			}
+		}
+	}
+
+	annotations := lab.AnnotationsBetween(1.5, 2.75, Overlapping)
+	if len(annotations) != 2 || annotations[0].GetLabel() != "b" || annotations[1].GetLabel() != "c" {
+		t.Fatalf("wanted annotations b and c, recieved %v", annotations)
+	}
+}
+
+// queryBenchmarkLab creates a Lab of count consecutive annotations of 10 milliseconds each.
+func queryBenchmarkLab(count int) Lab {
+	annotations := make([]Annotation, count)
+	for i := range annotations {
+		annotations[i] = Annotation{start: float64(i) * 0.01, end: float64(i+1) * 0.01, label: "a"}
+	}
+
+	lab := Lab{}
+	lab.SetAnnotations(annotations)
+	return lab
+}
+
+// BenchmarkIndexAt looks up times in labs of different lengths. The time per lookup should barely grow with the length.
+func BenchmarkIndexAt(b *testing.B) {
+	for _, count := range []int{1000, 100000} {
+		b.Run(fmt.Sprintf("%d annotations", count), func(b *testing.B) {
+			lab := queryBenchmarkLab(count)
+			lab.IndexAt(0)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := range b.N {
+				lab.IndexAt(float64(i%count) * 0.01)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package htk
+
+import (
+	"fmt"
+	"math"
+)
+
+// GetSampleRate gets the sample rate of an Annotation. Returns 0 if no sample rate is set.
+func (annotation *Annotation) GetSampleRate() int {
+	return annotation.sampleRate
+}
+
+// SetSampleRate sets the sample rate of an Annotation, which is used by its sample and frame methods.
+func (annotation *Annotation) SetSampleRate(sampleRate int) {
+	annotation.sampleRate = sampleRate
+}
+
+// GetStartSample gets the start time of an Annotation as the index of the nearest sample. Returns an error if no sample rate is set.
+func (annotation *Annotation) GetStartSample() (int64, error) {
+	if annotation.sampleRate <= 0 {
+		return 0, fmt.Errorf("error: cannot get start sample of annotation %q without a sample rate", annotation.label)
+	}
+
+	return toSample(annotation.start, annotation.sampleRate), nil
+}
+
+// SetStartSample sets the start time of an Annotation to a sample index. Returns an error if no sample rate is set.
+func (annotation *Annotation) SetStartSample(sample int64) error {
+	if annotation.sampleRate <= 0 {
+		return fmt.Errorf("error: cannot set start sample of annotation %q without a sample rate", annotation.label)
+	}
+
+	annotation.start = float64(sample) / float64(annotation.sampleRate)
+	return nil
+}
+
+// GetEndSample gets the end time of an Annotation as the index of the nearest sample. Returns an error if no sample rate is set.
+func (annotation *Annotation) GetEndSample() (int64, error) {
+	if annotation.sampleRate <= 0 {
+		return 0, fmt.Errorf("error: cannot get end sample of annotation %q without a sample rate", annotation.label)
+	}
+
+	return toSample(annotation.end, annotation.sampleRate), nil
+}
+
+// SetEndSample sets the end time of an Annotation to a sample index. Returns an error if no sample rate is set.
+func (annotation *Annotation) SetEndSample(sample int64) error {
+	if annotation.sampleRate <= 0 {
+		return fmt.Errorf("error: cannot set end sample of annotation %q without a sample rate", annotation.label)
+	}
+
+	annotation.end = float64(sample) / float64(annotation.sampleRate)
+	return nil
+}
+
+// GetStartFrame gets the start time of an Annotation as the index of the nearest frame, with frames hopSize samples apart.
+// Returns an error if no sample rate is set or hopSize is not positive.
+func (annotation *Annotation) GetStartFrame(hopSize int) (int64, error) {
+	if annotation.sampleRate <= 0 || hopSize <= 0 {
+		return 0, fmt.Errorf("error: cannot get start frame of annotation %q with sample rate %d and hop size %d", annotation.label, annotation.sampleRate, hopSize)
+	}
+
+	return toFrame(annotation.start, annotation.sampleRate, hopSize), nil
+}
+
+// SetStartFrame sets the start time of an Annotation to a frame index, with frames hopSize samples apart.
+// Returns an error if no sample rate is set or hopSize is not positive.
+func (annotation *Annotation) SetStartFrame(frame int64, hopSize int) error {
+	if hopSize <= 0 {
+		return fmt.Errorf("error: cannot set start frame of annotation %q with hop size %d", annotation.label, hopSize)
+	}
+
+	return annotation.SetStartSample(frame * int64(hopSize))
+}
+
+// GetEndFrame gets the end time of an Annotation as the index of the nearest frame, with frames hopSize samples apart.
+// Returns an error if no sample rate is set or hopSize is not positive.
+func (annotation *Annotation) GetEndFrame(hopSize int) (int64, error) {
+	if annotation.sampleRate <= 0 || hopSize <= 0 {
+		return 0, fmt.Errorf("error: cannot get end frame of annotation %q with sample rate %d and hop size %d", annotation.label, annotation.sampleRate, hopSize)
+	}
+
+	return toFrame(annotation.end, annotation.sampleRate, hopSize), nil
+}
+
+// SetEndFrame sets the end time of an Annotation to a frame index, with frames hopSize samples apart.
+// Returns an error if no sample rate is set or hopSize is not positive.
+func (annotation *Annotation) SetEndFrame(frame int64, hopSize int) error {
+	if hopSize <= 0 {
+		return fmt.Errorf("error: cannot set end frame of annotation %q with hop size %d", annotation.label, hopSize)
+	}
+
+	return annotation.SetEndSample(frame * int64(hopSize))
+}
+
+// SnapToSamples moves every time of a Lab, including alternative transcriptions, onto the nearest sample of its sample rate.
+// Returns an error if the Lab has no sample rate.
+func (lab *Lab) SnapToSamples() error {
+	if lab.sampleRate <= 0 {
+		return fmt.Errorf("error: cannot snap lab %s to samples without a sample rate", lab.name)
+	}
+
+	rate := float64(lab.sampleRate)
+	lab.forEachAnnotation(func(annotation *Annotation) {
+		annotation.start = math.Round(annotation.start*rate) / rate
+		annotation.end = math.Round(annotation.end*rate) / rate
+	})
+	lab.span = math.Round(lab.span*rate) / rate
+
+	return nil
+}
+
+// Quantize moves the boundaries of a Lab, including alternative transcriptions, onto a grid of step seconds, such as 0.005 for HTS frames.
+// The grid starts at the first start time, and the first start and last end times are kept so the total duration is unchanged.
+// Each boundary is rounded on its own instead of from the previous one, so rounding errors do not add up over long labs.
+func (lab *Lab) Quantize(step float64) error {
+	if step <= 0 || math.IsInf(step, 0) || math.IsNaN(step) {
+		return fmt.Errorf("error: cannot quantize lab %s to step %s, it must be positive", lab.name, f2s(step))
+	}
+
+	lab.annotations = quantizeAnnotations(lab.annotations, step)
+	lab.ends = nil
+	for i, alternative := range lab.alternatives {
+		lab.alternatives[i] = quantizeAnnotations(alternative, step)
+	}
+
+	return nil
+}
+
+// quantizeAnnotations rounds the interior boundaries of a list of annotations onto a grid of step seconds starting at the first start time.
+func quantizeAnnotations(annotations []Annotation, step float64) []Annotation {
+	if len(annotations) == 0 {
+		return annotations
+	}
+
+	origin := annotations[0].start
+	last := annotations[len(annotations)-1].end
+
+	snap := func(time float64) float64 {
+		snapped := origin + math.Round((time-origin)/step)*step
+		return math.Min(math.Max(snapped, origin), last)
+	}
+
+	for i := range annotations {
+		if i > 0 {
+			annotations[i].start = snap(annotations[i].start)
+		}
+		if i < len(annotations)-1 {
+			annotations[i].end = snap(annotations[i].end)
+		}
+	}
+
+	return annotations
+}
+
+// toSample converts a time in seconds into the index of the nearest sample. Returns 0 if the sample rate is not positive.
+func toSample(seconds float64, sampleRate int) int64 {
+	if sampleRate <= 0 {
+		return 0
+	}
+
+	return int64(math.Round(seconds * float64(sampleRate)))
+}
+
+// toFrame converts a time in seconds into the index of the nearest frame. Returns 0 if the sample rate or hop size is not positive.
+func toFrame(seconds float64, sampleRate int, hopSize int) int64 {
+	if sampleRate <= 0 || hopSize <= 0 {
+		return 0
+	}
+
+	return int64(math.Round(seconds * float64(sampleRate) / float64(hopSize)))
+}
@@ -0,0 +1,185 @@
+package htk
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestAnnotationSamples(t *testing.T) {
+	annotation := Annotation{start: 0.5, end: 1.0, label: "a"}
+
+	if _, err := annotation.GetStartSample(); err == nil {
+		t.Fatal("wanted error getting sample without a sample rate")
+	}
+	if _, err := annotation.GetEndFrame(240); err == nil {
+		t.Fatal("wanted error getting frame without a sample rate")
+	}
+	if annotation.SetStartSample(10) == nil {
+		t.Fatal("wanted error setting sample without a sample rate")
+	}
+
+	annotation.SetSampleRate(48000)
+	start, _ := annotation.GetStartSample()
+	end, _ := annotation.GetEndSample()
+	if start != 24000 || end != 48000 {
+		t.Fatalf("wanted samples 24000 and 48000, recieved %d and %d", start, end)
+	}
+
+	err := annotation.SetEndSample(36000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if annotation.GetEnd() != 0.75 {
+		t.Fatalf("wanted end 0.75, recieved %f", annotation.GetEnd())
+	}
+
+	// 240 sample hops are 5ms frames at 48kHz
+	start, _ = annotation.GetStartFrame(240)
+	end, _ = annotation.GetEndFrame(240)
+	if start != 100 || end != 150 {
+		t.Fatalf("wanted frames 100 and 150, recieved %d and %d", start, end)
+	}
+	if _, err := annotation.GetStartFrame(0); err == nil {
+		t.Fatal("wanted error getting frame with hop size 0")
+	}
+
+	err = annotation.SetStartFrame(120, 240)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start, _ := annotation.GetStartSample(); start != 28800 {
+		t.Fatalf("wanted sample 28800, recieved %d", start)
+	}
+
+	if annotation.SetEndFrame(1, 0) == nil {
+		t.Fatal("wanted error for hop size 0")
+	}
+}
+
+func TestLabSampleRate(t *testing.T) {
+	lab := Lab{}
+	lab.PushAnnotation(Annotation{start: 0, end: 1, label: "a"})
+	lab.SetSampleRate(16000)
+
+	if lab.GetAnnotations()[0].GetSampleRate() != 16000 {
+		t.Fatalf("wanted annotation sample rate 16000, recieved %d", lab.GetAnnotations()[0].GetSampleRate())
+	}
+
+	lab.PushAnnotation(Annotation{start: 1, end: 2, label: "b"})
+	if end, err := lab.GetAnnotations()[1].GetEndSample(); end != 32000 || err != nil {
+		t.Fatalf("wanted pushed annotation to end at sample 32000, recieved %d and %v", end, err)
+	}
+
+	// annotations set at once take the sample rate too, without changing the slice they were set from
+	annotations := []Annotation{{start: 0, end: 0.5, label: "c"}}
+	lab.SetAnnotations(annotations)
+	if end, err := lab.GetAnnotations()[0].GetEndSample(); end != 8000 || err != nil {
+		t.Fatalf("wanted set annotation to end at sample 8000, recieved %d and %v", end, err)
+	}
+	if annotations[0].GetSampleRate() != 0 {
+		t.Fatalf("wanted original annotation to keep sample rate 0, recieved %d", annotations[0].GetSampleRate())
+	}
+
+	mapping := Mapping{}
+	err := mapping.AddRule([]string{"c"}, []string{"d", "e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping.Apply(&lab)
+	if end, err := lab.GetAnnotations()[1].GetEndSample(); end != 8000 || err != nil {
+		t.Fatalf("wanted mapped annotation to end at sample 8000, recieved %d and %v", end, err)
+	}
+
+	empty := Lab{}
+	if empty.SnapToSamples() == nil {
+		t.Fatal("wanted error snapping without a sample rate")
+	}
+}
+
+func TestSampleRoundTrip(t *testing.T) {
+	input := "0.0000000 0.1234567 a\n0.1234567 0.2469134 b\n"
+
+	lab, err := ParseLab(strings.NewReader(input), "round_trip", WithSampleRate(22050))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := lab.GetAnnotations()[0].GetEndSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// repeated writes and reads must not move any boundary off its sample
+	for i := 0; i < 5; i++ {
+		var buffer bytes.Buffer
+		_, err = lab.WriteTo(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lab, err = ParseLab(&buffer, "round_trip", WithSampleRate(22050))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	annotation := lab.GetAnnotations()[0]
+	if end, _ := annotation.GetEndSample(); end != first {
+		t.Fatalf("wanted end sample %d, recieved %d", first, end)
+	}
+	if annotation.GetEnd() != float64(first)/22050 {
+		t.Fatalf("wanted end exactly on sample %d, recieved %v", first, annotation.GetEnd())
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	lab := Lab{}
+	lab.AppendAnnotations([]Annotation{
+		{start: 0, end: 0.0123, label: "a"},
+		{start: 0.0123, end: 0.0251, label: "b"},
+		{start: 0.0251, end: 0.0337, label: "c"},
+	})
+
+	err := lab.Quantize(0.005)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][2]float64{{0, 0.01}, {0.01, 0.025}, {0.025, 0.0337}}
+	for i, annotation := range lab.GetAnnotations() {
+		if math.Abs(annotation.GetStart()-expected[i][0]) > 1e-12 || math.Abs(annotation.GetEnd()-expected[i][1]) > 1e-12 {
+			t.Fatalf("wanted annotation %d at %v, recieved [%v, %v]", i, expected[i], annotation.GetStart(), annotation.GetEnd())
+		}
+	}
+
+	if lab.GetDuration() != 0.0337 {
+		t.Fatalf("wanted duration 0.0337 to be kept, recieved %v", lab.GetDuration())
+	}
+
+	if lab.Quantize(0) == nil {
+		t.Fatal("wanted error for step 0")
+	}
+}
+
+func TestQuantizeLongLab(t *testing.T) {
+	lab := Lab{}
+
+	// many boundaries at 5.1ms steps would drift if each was rounded from the previous one
+	for i := 0; i < 10000; i++ {
+		lab.PushAnnotation(Annotation{start: float64(i) * 0.0051, end: float64(i+1) * 0.0051, label: "x"})
+	}
+
+	err := lab.Quantize(0.005)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, annotation := range lab.GetAnnotations()[:len(lab.GetAnnotations())-1] {
+		original := float64(i+1) * 0.0051
+		if math.Abs(annotation.GetEnd()-original) > 0.0025+1e-9 {
+			t.Fatalf("wanted annotation %d to end within half a step of %v, recieved %v", i, original, annotation.GetEnd())
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package htk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+)
+
+// defaultBinWidth is the width in seconds of the histogram bins of a Statistics, when none is given.
+const defaultBinWidth = 0.01
+
+// Statistics accumulates the durations of annotations across many labs, grouped by label.
+// Only running totals and a histogram are kept for each label, so memory use does not grow with the size of the corpus.
+// Medians are estimated from the histogram, and are accurate to within one bin width.
+type Statistics struct {
+	labels   map[string]*labelStatistics
+	binWidth float64
+	labCount int
+}
+
+// labelStatistics holds the running totals of a single label. Mean and m2 are updated with Welford's algorithm.
+type labelStatistics struct {
+	count     int
+	total     float64
+	mean      float64
+	m2        float64
+	min       float64
+	max       float64
+	histogram map[int]int
+}
+
+// LabelSummary holds the duration statistics of a single label, in seconds.
+// StdDev is the sample standard deviation, which is 0 for labels seen only once.
+type LabelSummary struct {
+	Label     string         `json:"label"`
+	Count     int            `json:"count"`
+	Total     float64        `json:"total"`
+	Mean      float64        `json:"mean"`
+	Median    float64        `json:"median"`
+	StdDev    float64        `json:"std_dev"`
+	Min       float64        `json:"min"`
+	Max       float64        `json:"max"`
+	Histogram []HistogramBin `json:"histogram"`
+}
+
+// HistogramBin is the number of annotations with a duration from Start up to but not including End.
+type HistogramBin struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Count int     `json:"count"`
+}
+
+// Outlier is an Annotation whose duration is unusually far from the mean duration of its label.
+// ZScore is the number of standard deviations between the duration and the mean.
+type Outlier struct {
+	Index    int
+	Label    string
+	Duration float64
+	ZScore   float64
+	Line     int
+}
+
+// NewStatistics creates an empty Statistics with histogram bins of the given width in seconds, which defaults to 10 milliseconds.
+func NewStatistics(binWidth ...float64) Statistics {
+	// if no bin width is specified, default to 10ms
+	if len(binWidth) == 0 || binWidth[0] <= 0 {
+		binWidth = []float64{defaultBinWidth}
+	}
+
+	return Statistics{labels: make(map[string]*labelStatistics), binWidth: binWidth[0]}
+}
+
+// GetBinWidth gets the width in seconds of the histogram bins of a Statistics.
+func (statistics *Statistics) GetBinWidth() float64 {
+	return statistics.binWidth
+}
+
+// GetLabCount gets the number of labs added to a Statistics.
+func (statistics *Statistics) GetLabCount() int {
+	return statistics.labCount
+}
+
+// Add adds the durations of every Annotation of a Lab to a Statistics. Alternative transcriptions are not included.
+func (statistics *Statistics) Add(lab *Lab) {
+	if statistics.labels == nil {
+		*statistics = NewStatistics(statistics.binWidth)
+	}
+	statistics.labCount++
+
+	for _, annotation := range lab.annotations {
+		statistics.addDuration(annotation.label, annotation.GetDuration())
+	}
+}
+
+// AddDirectory reads every .lab file in a directory and adds it to a Statistics, reading one Lab at a time.
+func (statistics *Statistics) AddDirectory(directory string, options ...ReadOption) error {
+	paths, err := filepath.Glob(filepath.Join(directory, "*.lab"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		lab, err := ReadLab(path, options...)
+		if err != nil {
+			return err
+		}
+
+		statistics.Add(&lab)
+	}
+
+	return nil
+}
+
+// addDuration updates the running totals of a label with a single duration.
+func (statistics *Statistics) addDuration(label string, duration float64) {
+	current, exists := statistics.labels[label]
+	if !exists {
+		current = &labelStatistics{min: duration, max: duration, histogram: make(map[int]int)}
+		statistics.labels[label] = current
+	}
+
+	current.count++
+	current.total += duration
+	current.min = math.Min(current.min, duration)
+	current.max = math.Max(current.max, duration)
+
+	delta := duration - current.mean
+	current.mean += delta / float64(current.count)
+	current.m2 += delta * (duration - current.mean)
+
+	current.histogram[int(math.Floor(duration/statistics.binWidth))]++
+}
+
+// stdDev returns the sample standard deviation of the durations of a label, or 0 if it has been seen only once.
+func (current *labelStatistics) stdDev() float64 {
+	if current.count < 2 {
+		return 0
+	}
+
+	return math.Sqrt(current.m2 / float64(current.count-1))
+}
+
+// GetLabels gets every label seen by a Statistics, sorted alphabetically.
+func (statistics *Statistics) GetLabels() []string {
+	var labels []string
+	for label := range statistics.labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	return labels
+}
+
+// GetSummary gets the LabelSummary of a single label. Returns false if the label has not been seen.
+func (statistics *Statistics) GetSummary(label string) (LabelSummary, bool) {
+	current, exists := statistics.labels[label]
+	if !exists {
+		return LabelSummary{}, false
+	}
+
+	summary := LabelSummary{
+		Label: label,
+		Count: current.count,
+		Total: current.total,
+		Mean:  current.mean,
+		Min:   current.min,
+		Max:   current.max,
+	}
+	summary.StdDev = current.stdDev()
+
+	var bins []int
+	for bin := range current.histogram {
+		bins = append(bins, bin)
+	}
+	sort.Ints(bins)
+
+	for _, bin := range bins {
+		summary.Histogram = append(summary.Histogram, HistogramBin{
+			Start: float64(bin) * statistics.binWidth,
+			End:   float64(bin+1) * statistics.binWidth,
+			Count: current.histogram[bin],
+		})
+	}
+	summary.Median = histogramMedian(summary.Histogram, current.count, current.min, current.max)
+
+	return summary, true
+}
+
+// GetSummaries gets the LabelSummary of every label, sorted alphabetically by label.
+func (statistics *Statistics) GetSummaries() []LabelSummary {
+	var summaries []LabelSummary
+	for _, label := range statistics.GetLabels() {
+		summary, _ := statistics.GetSummary(label)
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
+
+// WriteJSON writes the summary of every label of a Statistics to an io.Writer as a JSON array, including histograms.
+func (statistics *Statistics) WriteJSON(writer io.Writer) error {
+	summaries := statistics.GetSummaries()
+	if summaries == nil {
+		summaries = []LabelSummary{}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summaries)
+}
+
+// WriteCSV writes the summary of every label of a Statistics to an io.Writer as CSV with a header row. Histograms are not included.
+func (statistics *Statistics) WriteCSV(writer io.Writer) error {
+	output := csv.NewWriter(writer)
+
+	err := output.Write([]string{"label", "count", "total", "mean", "median", "std_dev", "min", "max"})
+	if err != nil {
+		return err
+	}
+
+	for _, summary := range statistics.GetSummaries() {
+		err = output.Write([]string{
+			summary.Label,
+			fmt.Sprint(summary.Count),
+			f2s(summary.Total),
+			f2s(summary.Mean),
+			f2s(summary.Median),
+			f2s(summary.StdDev),
+			f2s(summary.Min),
+			f2s(summary.Max),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	output.Flush()
+	return output.Error()
+}
+
+// Outliers finds the annotations of a Lab whose duration is more than z standard deviations from the mean duration of their label.
+// Labels not seen by the Statistics, or without any variation in duration, are skipped.
+func (statistics *Statistics) Outliers(lab *Lab, z float64) []Outlier {
+	var outliers []Outlier
+
+	for i, annotation := range lab.annotations {
+		// the running totals are used directly, since building a full summary sorts the histogram
+		current, exists := statistics.labels[annotation.label]
+		if !exists || current.stdDev() == 0 {
+			continue
+		}
+
+		score := (annotation.GetDuration() - current.mean) / current.stdDev()
+		if math.Abs(score) > z {
+			outliers = append(outliers, Outlier{
+				Index:    i,
+				Label:    annotation.label,
+				Duration: annotation.GetDuration(),
+				ZScore:   score,
+				Line:     annotation.line,
+			})
+		}
+	}
+
+	return outliers
+}
+
+// histogramMedian estimates the median of a histogram by interpolating inside the bin holding the middle value.
+func histogramMedian(histogram []HistogramBin, count int, minimum float64, maximum float64) float64 {
+	target := float64(count) / 2
+	cumulative := 0
+
+	for _, bin := range histogram {
+		if float64(cumulative+bin.Count) >= target {
+			estimate := bin.Start + (target-float64(cumulative))/float64(bin.Count)*(bin.End-bin.Start)
+			return math.Min(math.Max(estimate, minimum), maximum)
+		}
+
+		cumulative += bin.Count
+	}
+
+	return maximum
+}
@@ -0,0 +1,127 @@
+package htk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestStatistics(t *testing.T) {
+	statistics := NewStatistics()
+
+	err := statistics.AddDirectory("examples/statistics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if statistics.GetLabCount() != 2 {
+		t.Fatalf("wanted 2 labs, recieved %d", statistics.GetLabCount())
+	}
+	if !isEqualSlice(statistics.GetLabels(), []string{"a", "b"}) {
+		t.Fatalf("wanted labels [a b], recieved %v", statistics.GetLabels())
+	}
+
+	summary, ok := statistics.GetSummary("b")
+	if !ok {
+		t.Fatal("wanted summary for b")
+	}
+	if summary.Count != 2 || math.Abs(summary.Total-0.32) > 1e-9 || math.Abs(summary.Mean-0.16) > 1e-9 {
+		t.Fatalf("wanted count 2, total 0.32 and mean 0.16, recieved %d, %v and %v", summary.Count, summary.Total, summary.Mean)
+	}
+	if math.Abs(summary.Min-0.12) > 1e-9 || math.Abs(summary.Max-0.2) > 1e-9 {
+		t.Fatalf("wanted min 0.12 and max 0.2, recieved %v and %v", summary.Min, summary.Max)
+	}
+	if math.Abs(summary.StdDev-math.Sqrt(0.0032)) > 1e-9 {
+		t.Fatalf("wanted standard deviation %v, recieved %v", math.Sqrt(0.0032), summary.StdDev)
+	}
+
+	summary, _ = statistics.GetSummary("a")
+	if summary.Count != 5 {
+		t.Fatalf("wanted count 5, recieved %d", summary.Count)
+	}
+
+	// the median of 0.1, 0.1, 0.12, 0.14 and 1.0 is 0.12, estimated within a 10ms bin
+	if math.Abs(summary.Median-0.12) > statistics.GetBinWidth() {
+		t.Fatalf("wanted median near 0.12, recieved %v", summary.Median)
+	}
+
+	total := 0
+	for _, bin := range summary.Histogram {
+		total += bin.Count
+	}
+	if total != 5 {
+		t.Fatalf("wanted 5 durations in histogram, recieved %d", total)
+	}
+
+	if _, ok := statistics.GetSummary("z"); ok {
+		t.Fatal("wanted no summary for unseen label")
+	}
+}
+
+func TestStatisticsOutput(t *testing.T) {
+	statistics := NewStatistics(0.05)
+	err := statistics.AddDirectory("examples/statistics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	err = statistics.WriteJSON(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var summaries []LabelSummary
+	err = json.Unmarshal(buffer.Bytes(), &summaries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 2 || summaries[0].Label != "a" || len(summaries[0].Histogram) == 0 {
+		t.Fatalf("malformed json summaries, recieved %v", summaries)
+	}
+
+	buffer.Reset()
+	err = statistics.WriteCSV(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0][0] != "label" || records[2][0] != "b" || records[2][1] != "2" {
+		t.Fatalf("malformed csv summaries, recieved %v", records)
+	}
+
+	empty := Statistics{}
+	buffer.Reset()
+	err = empty.WriteJSON(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != "[]\n" {
+		t.Fatalf("wanted empty json array, recieved %q", buffer.String())
+	}
+}
+
+func TestOutliers(t *testing.T) {
+	statistics := Statistics{}
+
+	lab := Lab{}
+	for i := 0; i < 20; i++ {
+		lab.PushAnnotation(Annotation{start: float64(i) * 0.1, end: float64(i+1)*0.1 + float64(i%2)*0.01, label: "a"})
+	}
+	lab.PushAnnotation(Annotation{start: 2, end: 3, label: "a"})
+	statistics.Add(&lab)
+
+	outliers := statistics.Outliers(&lab, 3)
+	if len(outliers) != 1 || outliers[0].Index != 20 {
+		t.Fatalf("wanted outlier at index 20, recieved %v", outliers)
+	}
+	if outliers[0].ZScore < 3 {
+		t.Fatalf("wanted z-score above 3, recieved %v", outliers[0].ZScore)
+	}
+}
@@ -0,0 +1,206 @@
+package htk
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/vocatart/golab/textgrid"
+)
+
+// maxPrecision is the highest precision chosen when converting from a TextGrid, matching the default precision of a Lab.
+const maxPrecision = 7
+
+// ToIntervalTier converts the annotations of a Lab into a textgrid.IntervalTier with the given name.
+// Bounds are an optional xmin and xmax of the tier, which default to 0 (or the first start time if it is negative) and the last end time.
+// Gaps between annotations and the bounds are filled with empty intervals, and times are rounded to the TimeUnit of the Lab.
+// Returns an error if annotations overlap or lie outside of the bounds, since an IntervalTier cannot represent them.
+func (lab *Lab) ToIntervalTier(name string, bounds ...float64) (textgrid.IntervalTier, error) {
+	tier := textgrid.IntervalTier{}
+	tier.SetName(name)
+
+	xmin, xmax := lab.tierBounds(bounds)
+	if xmax < xmin {
+		return tier, fmt.Errorf("error: cannot create tier %s with xmax %s before xmin %s", name, f2s(xmax), f2s(xmin))
+	}
+
+	var intervals []textgrid.Interval
+	previousEnd := xmin
+
+	for i, annotation := range lab.annotations {
+		start := lab.roundTime(annotation.start)
+		end := lab.roundTime(annotation.end)
+
+		if start < previousEnd {
+			if i == 0 {
+				return tier, fmt.Errorf("error: annotation %d of lab %s starts at %s, before tier xmin %s", i, lab.name, f2s(start), f2s(xmin))
+			}
+			return tier, fmt.Errorf("error: annotation %d of lab %s overlaps the previous annotation", i, lab.name)
+		}
+		if end < start {
+			return tier, fmt.Errorf("error: annotation %d of lab %s ends before it starts", i, lab.name)
+		}
+		if end > xmax {
+			return tier, fmt.Errorf("error: annotation %d of lab %s ends at %s, after tier xmax %s", i, lab.name, f2s(end), f2s(xmax))
+		}
+
+		// fill the gap before the annotation
+		if start > previousEnd {
+			intervals = append(intervals, newInterval(previousEnd, start, ""))
+		}
+
+		intervals = append(intervals, newInterval(start, end, annotation.label))
+		previousEnd = end
+	}
+
+	// fill the gap after the last annotation, which also makes sure the tier is never empty
+	if previousEnd < xmax || len(intervals) == 0 {
+		intervals = append(intervals, newInterval(previousEnd, xmax, ""))
+	}
+
+	// the intervals are checked above, so the tier does not need to check them against its bounds again
+	err := tier.SetIntervals(intervals, false)
+	if err != nil {
+		return tier, err
+	}
+	err = tier.SetXmin(xmin, false)
+	if err != nil {
+		return tier, err
+	}
+	err = tier.SetXmax(xmax, false)
+	if err != nil {
+		return tier, err
+	}
+
+	return tier, nil
+}
+
+// ToTextGrid converts the annotations of a Lab into a textgrid.TextGrid with a single IntervalTier with the given name.
+// The TextGrid is named after the Lab, without its extension. See ToIntervalTier for how bounds and gaps are handled.
+func (lab *Lab) ToTextGrid(tierName string, bounds ...float64) (textgrid.TextGrid, error) {
+	tg := textgrid.TextGrid{}
+
+	tier, err := lab.ToIntervalTier(tierName, bounds...)
+	if err != nil {
+		return tg, err
+	}
+
+	tg.SetName(strings.TrimSuffix(lab.name, filepath.Ext(lab.name)))
+	tg.SetXmin(tier.GetXmin())
+	tg.SetXmax(tier.GetXmax())
+	tg.SetTiers([]textgrid.Tier{&tier})
+
+	return tg, nil
+}
+
+// FromIntervalTier converts a textgrid.IntervalTier into a Lab named after the tier. Intervals with empty text are treated as gaps and skipped.
+// The precision of the Lab can be given, otherwise the lowest precision (between 1 and 7) that represents every time in the tier is chosen.
+func FromIntervalTier(tier textgrid.Tier, precision ...uint8) (Lab, error) {
+	lab := Lab{name: tier.GetName()}
+
+	if tier.GetType() != "IntervalTier" {
+		return lab, fmt.Errorf("error: cannot convert %s %s into a lab (type mismatch)", tier.GetType(), tier.GetName())
+	}
+
+	var times []float64
+	for _, interval := range tier.GetIntervals() {
+		times = append(times, interval.GetXmin(), interval.GetXmax())
+
+		if interval.GetText() == "" {
+			continue
+		}
+
+		lab.PushAnnotation(Annotation{start: interval.GetXmin(), end: interval.GetXmax(), label: interval.GetText()})
+	}
+
+	if len(precision) == 0 {
+		lab.precision = detectPrecision(times)
+	} else {
+		lab.precision = precision[0]
+	}
+
+	return lab, nil
+}
+
+// FromTextGrid converts the IntervalTier with the given name in a textgrid.TextGrid into a Lab named after the TextGrid.
+// See FromIntervalTier for how intervals and precision are handled.
+func FromTextGrid(tg textgrid.TextGrid, tierName string, precision ...uint8) (Lab, error) {
+	tier := tg.GetTier(tierName)
+	if tier == nil {
+		return Lab{}, fmt.Errorf("error: textgrid %s has no tier named %s", tg.GetName(), tierName)
+	}
+
+	lab, err := FromIntervalTier(tier, precision...)
+	lab.name = strings.TrimSuffix(tg.GetName(), filepath.Ext(tg.GetName()))
+
+	return lab, err
+}
+
+// tierBounds returns the xmin and xmax of a tier created from a Lab, using the given bounds where available.
+func (lab *Lab) tierBounds(bounds []float64) (float64, float64) {
+	xmin := 0.0
+	if len(lab.annotations) > 0 {
+		xmin = math.Min(xmin, lab.roundTime(lab.annotations[0].start))
+	}
+
+	xmax := xmin
+	for _, annotation := range lab.annotations {
+		xmax = math.Max(xmax, lab.roundTime(annotation.end))
+	}
+
+	if len(bounds) > 0 {
+		xmin = bounds[0]
+	}
+	if len(bounds) > 1 {
+		xmax = bounds[1]
+	}
+
+	return xmin, xmax
+}
+
+// roundTime rounds a time in seconds to the grid of the TimeUnit of a Lab, removing floating point noise from converted times.
+// Times are not rounded when the Lab is written in Seconds, since the precision of a Lab may be lower than the precision of its times.
+func (lab *Lab) roundTime(seconds float64) float64 {
+	scale, err := lab.unitsPerSecond()
+	if err != nil || lab.unit == Seconds {
+		return seconds
+	}
+
+	return math.Round(seconds*scale) / scale
+}
+
+// detectPrecision returns the lowest precision between 1 and maxPrecision that represents every time exactly.
+// A precision of at least 1 makes sure the Lab is not read back as HTKUnits.
+func detectPrecision(times []float64) uint8 {
+	precision := uint8(1)
+
+	for _, time := range times {
+		formatted := f2s(time)
+
+		periodIndex := strings.Index(formatted, ".")
+		if periodIndex == -1 {
+			continue
+		}
+
+		decimals := len(formatted) - periodIndex - 1
+		if decimals >= maxPrecision {
+			return maxPrecision
+		}
+		if uint8(decimals) > precision {
+			precision = uint8(decimals)
+		}
+	}
+
+	return precision
+}
+
+// newInterval creates a textgrid.Interval from its xmin, xmax and text.
+func newInterval(xmin float64, xmax float64, text string) textgrid.Interval {
+	interval := textgrid.Interval{}
+	interval.SetXmin(xmin)
+	interval.SetXmax(xmax)
+	interval.SetText(text)
+
+	return interval
+}
@@ -0,0 +1,126 @@
+package htk
+
+import (
+	"testing"
+
+	"github.com/vocatart/golab/textgrid"
+)
+
+func TestConvertingLabToIntervalTier(t *testing.T) {
+	lab, err := ReadLab("examples/gaps.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tier, err := lab.ToIntervalTier("phonemes", 0, 2.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		xmin float64
+		xmax float64
+		text string
+	}{
+		{0, 0.5, ""},
+		{0.5, 1.0, "a"},
+		{1.0, 1.2, ""},
+		{1.2, 2.0, "i"},
+		{2.0, 2.5, ""},
+	}
+
+	intervals := tier.GetIntervals()
+	if len(intervals) != len(expected) {
+		t.Fatalf("wanted %d intervals, recieved %d", len(expected), len(intervals))
+	}
+	for i, interval := range intervals {
+		if interval.GetXmin() != expected[i].xmin || interval.GetXmax() != expected[i].xmax || interval.GetText() != expected[i].text {
+			t.Errorf("wanted interval %v, recieved [%f, %f, %q]", expected[i], interval.GetXmin(), interval.GetXmax(), interval.GetText())
+		}
+	}
+
+	if tier.GetName() != "phonemes" || tier.GetXmin() != 0 || tier.GetXmax() != 2.5 {
+		t.Fatalf("wanted tier phonemes [0, 2.5], recieved %s [%f, %f]", tier.GetName(), tier.GetXmin(), tier.GetXmax())
+	}
+
+	_, err = lab.ToIntervalTier("phonemes", 0, 1.5)
+	if err == nil {
+		t.Fatal("wanted error for annotations outside of tier bounds")
+	}
+}
+
+func TestConvertingOverlappingLab(t *testing.T) {
+	lab := Lab{}
+	lab.SetAnnotations([]Annotation{
+		{start: 0, end: 1, label: "a"},
+		{start: 0.5, end: 2, label: "b"},
+	})
+
+	_, err := lab.ToIntervalTier("phonemes")
+	if err == nil {
+		t.Fatal("wanted error for overlapping annotations")
+	}
+}
+
+func TestConvertingLabToTextGrid(t *testing.T) {
+	lab, err := ReadLab("examples/01.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tg, err := lab.ToTextGrid("phonemes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tg.GetName() != "01" || tg.GetSize() != 1 {
+		t.Fatalf("wanted textgrid 01 with 1 tier, recieved %s with %d tiers", tg.GetName(), tg.GetSize())
+	}
+
+	converted, err := FromTextGrid(tg, "phonemes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if converted.ToString() != lab.ToString() {
+		t.Fatal("lab did not round-trip through textgrid")
+	}
+}
+
+func TestConvertingTextGridToLab(t *testing.T) {
+	tg, err := textgrid.ReadTextgrid("../textgrid/examples/long.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lab, err := FromTextGrid(tg, "John", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.ToString() != "0.0000 1.2403 2_label1\n1.2403 2.3510 2_label2\n" {
+		t.Fatalf("malformed lab string, recieved %q", lab.ToString())
+	}
+
+	_, err = FromTextGrid(tg, "Bell")
+	if err == nil {
+		t.Fatal("wanted error when converting a point tier")
+	}
+	_, err = FromTextGrid(tg, "Nobody")
+	if err == nil {
+		t.Fatal("wanted error when converting a tier that does not exist")
+	}
+}
+
+func TestDetectingPrecision(t *testing.T) {
+	if detectPrecision([]float64{0, 1, 2}) != 1 {
+		t.Error("wanted precision 1 for integer times")
+	}
+	if detectPrecision([]float64{0.25, 1.125}) != 3 {
+		t.Error("wanted precision 3")
+	}
+	tenth, fifth := 0.1, 0.2
+	if detectPrecision([]float64{tenth + fifth}) != 7 {
+		t.Error("wanted precision capped at 7")
+	}
+}
@@ -0,0 +1,70 @@
+package htk
+
+import "testing"
+
+func TestDetectingHTKUnits(t *testing.T) {
+	lab, err := ReadLab("examples/hvite.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.GetTimeUnit() != HTKUnits {
+		t.Fatalf("wanted time unit htk, recieved %s", lab.GetTimeUnit())
+	} else if lab.GetAnnotations()[1].GetStart() != 0.25 {
+		t.Fatalf("wanted start time 0.25, recieved %f", lab.GetAnnotations()[1].GetStart())
+	}
+}
+
+func TestDetectingSeconds(t *testing.T) {
+	lab, err := ReadLab("examples/short.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.GetTimeUnit() != Seconds {
+		t.Fatalf("wanted time unit seconds, recieved %s", lab.GetTimeUnit())
+	}
+}
+
+func TestReadingExplicitTimeUnit(t *testing.T) {
+	lab, err := ReadLab("examples/hvite.lab", WithTimeUnit(Seconds))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.GetAnnotations()[1].GetStart() != 2500000 {
+		t.Fatalf("wanted start time 2500000, recieved %f", lab.GetAnnotations()[1].GetStart())
+	}
+
+	lab, err = ReadLab("examples/hvite.lab", WithTimeUnit(Samples), WithSampleRate(10000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lab.GetAnnotations()[1].GetStart() != 0.25 {
+		t.Fatalf("wanted start time 0.25, recieved %f", lab.GetAnnotations()[1].GetStart())
+	}
+
+	_, err = ReadLab("examples/hvite.lab", WithTimeUnit(Samples))
+	if err == nil {
+		t.Fatal("wanted error when reading samples without a sample rate")
+	}
+}
+
+func TestConvertingTimeUnits(t *testing.T) {
+	lab, err := ReadLab("examples/one_line.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lab.SetTimeUnit(HTKUnits)
+	if lab.ToString() != "0 100000000 test\n" {
+		t.Fatalf("wanted htk times, recieved %q", lab.ToString())
+	}
+
+	lab.SetTimeUnit(Samples)
+	lab.SetSampleRate(48000)
+	if lab.ToString() != "0 480000 test\n" {
+		t.Fatalf("wanted sample times, recieved %q", lab.ToString())
+	}
+}
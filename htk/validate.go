@@ -0,0 +1,122 @@
+package htk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// IssueKind is the type of problem found by Validate.
+type IssueKind uint8
+
+const (
+	// Gap is a stretch of time between two consecutive annotations that no Annotation covers.
+	Gap IssueKind = iota
+	// Overlap is a stretch of time covered by two consecutive annotations.
+	Overlap
+	// ZeroDuration is an Annotation that starts and ends at the same time.
+	ZeroDuration
+	// NegativeDuration is an Annotation that ends before it starts.
+	NegativeDuration
+	// NonMonotonic is an Annotation that starts before the Annotation preceding it.
+	NonMonotonic
+	// EmptyLabel is an Annotation without a label.
+	EmptyLabel
+)
+
+// String returns the name of an IssueKind.
+func (kind IssueKind) String() string {
+	switch kind {
+	case Gap:
+		return "gap"
+	case Overlap:
+		return "overlap"
+	case ZeroDuration:
+		return "zero duration"
+	case NegativeDuration:
+		return "negative duration"
+	case NonMonotonic:
+		return "non-monotonic start"
+	case EmptyLabel:
+		return "empty label"
+	default:
+		return fmt.Sprintf("IssueKind(%d)", uint8(kind))
+	}
+}
+
+// Issue is a single problem found by Validate.
+// Start and End are the times the problem covers, which for gaps and overlaps is the time between the two annotations.
+// Line is the line number the Annotation was read from, or 0 if it was not read from a file.
+type Issue struct {
+	Kind  IssueKind
+	Index int
+	Start float64
+	End   float64
+	Line  int
+}
+
+// String returns a readable description of an Issue.
+func (issue Issue) String() string {
+	description := fmt.Sprintf("%s at annotation %d [%s, %s]", issue.Kind, issue.Index, f2s(issue.Start), f2s(issue.End))
+	if issue.Line > 0 {
+		description += fmt.Sprintf(" (line %d)", issue.Line)
+	}
+
+	return description
+}
+
+// Validate checks the annotations of a Lab for gaps, overlaps, zero or negative durations, non-monotonic starts and empty labels.
+// Differences in time up to the tolerance are ignored, which defaults to 0. Returns nil if no issues are found.
+// Gaps, overlaps and non-monotonic starts are reported at the index of the second Annotation of the pair.
+func (lab *Lab) Validate(tolerance ...float64) []Issue {
+	// if no tolerance is specified, default to 0
+	if len(tolerance) == 0 {
+		tolerance = append(tolerance, 0)
+	}
+	epsilon := math.Abs(tolerance[0])
+
+	var issues []Issue
+
+	for i, annotation := range lab.annotations {
+		issue := Issue{Index: i, Start: annotation.start, End: annotation.end, Line: annotation.line}
+
+		if strings.TrimSpace(annotation.label) == "" {
+			issue.Kind = EmptyLabel
+			issues = append(issues, issue)
+		}
+
+		duration := annotation.end - annotation.start
+		if duration < -epsilon {
+			issue.Kind = NegativeDuration
+			issues = append(issues, issue)
+		} else if duration <= epsilon {
+			issue.Kind = ZeroDuration
+			issues = append(issues, issue)
+		}
+
+		if i == 0 {
+			continue
+		}
+		previous := lab.annotations[i-1]
+
+		if annotation.start < previous.start-epsilon {
+			issue.Kind = NonMonotonic
+			issue.Start, issue.End = annotation.start, previous.start
+			issues = append(issues, issue)
+			continue
+		}
+
+		// compare the boundary between the two annotations
+		if annotation.start-previous.end > epsilon {
+			issue.Kind = Gap
+			issue.Start, issue.End = previous.end, annotation.start
+			issues = append(issues, issue)
+		} else if previous.end-annotation.start > epsilon {
+			issue.Kind = Overlap
+			issue.Start, issue.End = annotation.start, previous.end
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
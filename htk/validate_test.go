@@ -0,0 +1,68 @@
+package htk
+
+import "testing"
+
+func TestValidatingLab(t *testing.T) {
+	lab, err := ReadLab("examples/invalid.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Issue{
+		{Kind: Gap, Index: 1, Start: 0.5, End: 0.6, Line: 2},
+		{Kind: Overlap, Index: 2, Start: 0.9, End: 1.0, Line: 3},
+		{Kind: ZeroDuration, Index: 3, Start: 1.2, End: 1.2, Line: 4},
+		{Kind: NegativeDuration, Index: 4, Start: 1.3, End: 1.25, Line: 5},
+		{Kind: Gap, Index: 4, Start: 1.2, End: 1.3, Line: 5},
+		{Kind: NonMonotonic, Index: 5, Start: 1.0, End: 1.3, Line: 6},
+	}
+
+	issues := lab.Validate()
+	if len(issues) != len(expected) {
+		t.Fatalf("wanted %d issues, recieved %d: %v", len(expected), len(issues), issues)
+	}
+
+	for i, issue := range issues {
+		if issue != expected[i] {
+			t.Errorf("wanted issue %v, recieved %v", expected[i], issue)
+		}
+	}
+}
+
+func TestValidatingWithTolerance(t *testing.T) {
+	lab := Lab{}
+	lab.SetAnnotations([]Annotation{
+		{start: 0, end: 1, label: "a"},
+		{start: 1.004, end: 2, label: ""},
+	})
+
+	issues := lab.Validate(0.005)
+	if len(issues) != 1 || issues[0].Kind != EmptyLabel {
+		t.Fatalf("wanted only an empty label issue, recieved %v", issues)
+	}
+
+	issues = lab.Validate()
+	if len(issues) != 2 || issues[1].Kind != Gap {
+		t.Fatalf("wanted an empty label and gap issue, recieved %v", issues)
+	}
+
+	if issues[1].String() != "gap at annotation 1 [1, 1.004]" {
+		t.Fatalf("malformed issue string, recieved %q", issues[1].String())
+	}
+}
+
+func TestValidatingValidLab(t *testing.T) {
+	lab, err := ReadLab("examples/01.lab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if issues := lab.Validate(); issues != nil {
+		t.Fatalf("wanted no issues, recieved %v", issues)
+	}
+
+	empty := Lab{}
+	if empty.GetDuration() != 0 || empty.Validate() != nil {
+		t.Fatal("wanted empty lab to have no duration and no issues")
+	}
+}
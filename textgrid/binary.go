@@ -0,0 +1,449 @@
+package textgrid
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf16"
+
+	"github.com/vocatart/golab/fileio"
+)
+
+// binaryMagic starts every binary Praat file, followed by the class name of the object in the file.
+const binaryMagic = "ooBinaryFile"
+
+// binaryHeader starts every binary TextGrid file. The class name is preceded by its length.
+var binaryHeader = []byte(binaryMagic + "\x08TextGrid")
+
+// binaryUTF16Marker replaces the length of a string holding non-ASCII characters, which is then written as UTF-16BE.
+const binaryUTF16Marker = 0xFFFF
+
+// isBinary returns true if data is a binary Praat file, holding any class of object.
+func isBinary(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(binaryMagic))
+}
+
+// binaryReader reads the big endian values of a binary TextGrid file, keeping track of its position.
+type binaryReader struct {
+	data   []byte
+	offset int
+}
+
+// next returns the next count bytes. Returns a ParseError if the file ends before the field.
+func (reader *binaryReader) next(count int, field string) ([]byte, error) {
+	if reader.offset+count > len(reader.data) {
+		return nil, &ParseError{Field: field, Cause: fmt.Errorf("at byte %d: %w", reader.offset, io.ErrUnexpectedEOF)}
+	}
+
+	value := reader.data[reader.offset : reader.offset+count]
+	reader.offset += count
+	return value, nil
+}
+
+// capacity limits the space reserved for a size read from the file to the bytes left, so a corrupt size cannot reserve too much memory.
+func (reader *binaryReader) capacity(size int) int {
+	return min(size, len(reader.data)-reader.offset)
+}
+
+// readByte reads an unsigned 8 bit integer.
+func (reader *binaryReader) readByte(field string) (byte, error) {
+	value, err := reader.next(1, field)
+	if err != nil {
+		return 0, err
+	}
+
+	return value[0], nil
+}
+
+// readUint16 reads an unsigned 16 bit integer.
+func (reader *binaryReader) readUint16(field string) (uint16, error) {
+	value, err := reader.next(2, field)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint16(value), nil
+}
+
+// readInt reads a signed 32 bit integer, which Praat uses for sizes.
+func (reader *binaryReader) readInt(field string) (int, error) {
+	start := reader.offset
+
+	value, err := reader.next(4, field)
+	if err != nil {
+		return 0, err
+	}
+
+	result := int(int32(binary.BigEndian.Uint32(value)))
+	if result < 0 {
+		return 0, &ParseError{Field: field, Cause: fmt.Errorf("at byte %d: negative size %d", start, result)}
+	}
+
+	return result, nil
+}
+
+// readFloat reads a 64 bit float.
+func (reader *binaryReader) readFloat(field string) (float64, error) {
+	value, err := reader.next(8, field)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float64frombits(binary.BigEndian.Uint64(value)), nil
+}
+
+// readShortString reads a string with an 8 bit length, used for class names.
+func (reader *binaryReader) readShortString(field string) (string, error) {
+	length, err := reader.readByte(field)
+	if err != nil {
+		return "", err
+	}
+
+	// non-ASCII strings are marked, then hold their length in UTF-16 code units
+	if length == 0xFF {
+		length, err = reader.readByte(field)
+		if err != nil {
+			return "", err
+		}
+
+		return reader.readUTF16(int(length), field)
+	}
+
+	value, err := reader.next(int(length), field)
+	return string(value), err
+}
+
+// readString reads a string with a 16 bit length, used for names and labels.
+func (reader *binaryReader) readString(field string) (string, error) {
+	length, err := reader.readUint16(field)
+	if err != nil {
+		return "", err
+	}
+
+	// non-ASCII strings are marked, then hold their length in UTF-16 code units
+	if length == binaryUTF16Marker {
+		length, err = reader.readUint16(field)
+		if err != nil {
+			return "", err
+		}
+
+		return reader.readUTF16(int(length), field)
+	}
+
+	value, err := reader.next(int(length), field)
+	return string(value), err
+}
+
+// readUTF16 reads a number of big endian UTF-16 code units.
+func (reader *binaryReader) readUTF16(length int, field string) (string, error) {
+	value, err := reader.next(length*2, field)
+	if err != nil {
+		return "", err
+	}
+
+	units := make([]uint16, length)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(value[i*2:])
+	}
+
+	return string(utf16.Decode(units)), nil
+}
+
+// parseBinary reads the contents of a binary TextGrid file into a TextGrid.
+func parseBinary(tg *TextGrid, data []byte) error {
+	reader, err := newBinaryReader(data, binaryHeader)
+	if err != nil {
+		return err
+	}
+
+	return reader.readBody(tg)
+}
+
+// newBinaryReader creates a binaryReader positioned after the header of a binary file, checking that the header matches.
+func newBinaryReader(data []byte, header []byte) (*binaryReader, error) {
+	if !bytes.HasPrefix(data, header) {
+		return nil, &ParseError{Field: "object class", Cause: fmt.Errorf("wanted header %q, recieved %q", header, data[:min(len(data), len(header))])}
+	}
+
+	return &binaryReader{data: data, offset: len(header)}, nil
+}
+
+// readBody reads a binary TextGrid following its header, which is also how TextGrids are stored inside a Collection.
+func (reader *binaryReader) readBody(tg *TextGrid) error {
+	globalXmin, err := reader.readFloat("xmin")
+	if err != nil {
+		return err
+	}
+
+	globalXmax, err := reader.readFloat("xmax")
+	if err != nil {
+		return err
+	}
+
+	tg.xmin = globalXmin
+	tg.xmax = globalXmax
+
+	// the tier status is a single byte, which is 0 if the textgrid has no tiers
+	exists, err := reader.readByte("tier status")
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return nil
+	}
+
+	numTiers, err := reader.readInt("tier count")
+	if err != nil {
+		return err
+	}
+
+	tiers := make([]Tier, 0, reader.capacity(numTiers))
+	for tierNum := range numTiers {
+		tierField := fmt.Sprintf("tier %d", tierNum+1)
+
+		tierType, err := reader.readShortString("class of " + tierField)
+		if err != nil {
+			return err
+		}
+
+		tierName, err := reader.readString("name of " + tierField)
+		if err != nil {
+			return err
+		}
+
+		tierXmin, err := reader.readFloat("xmin of " + tierField)
+		if err != nil {
+			return err
+		}
+
+		tierXmax, err := reader.readFloat("xmax of " + tierField)
+		if err != nil {
+			return err
+		}
+
+		// check to see if any boundaries are inconsistent
+		if tierXmin < globalXmin {
+			return &ParseError{Field: "xmin of " + tierField, Cause: fmt.Errorf("%s %s has xmin %f, when TextGrid xmin is %f", tierType, tierName, tierXmin, globalXmin)}
+		}
+		if tierXmax > globalXmax {
+			return &ParseError{Field: "xmax of " + tierField, Cause: fmt.Errorf("%s %s has xmax %f, when TextGrid xmax is %f", tierType, tierName, tierXmax, globalXmax)}
+		}
+
+		tierContentCount, err := reader.readInt("size of " + tierField)
+		if err != nil {
+			return err
+		}
+
+		switch tierType {
+		case "IntervalTier":
+			intervals := make([]Interval, 0, reader.capacity(tierContentCount))
+
+			for intervalNum := range tierContentCount {
+				intervalField := fmt.Sprintf("interval %d in %s", intervalNum+1, tierField)
+
+				intervalXmin, err := reader.readFloat("xmin of " + intervalField)
+				if err != nil {
+					return err
+				}
+
+				intervalXmax, err := reader.readFloat("xmax of " + intervalField)
+				if err != nil {
+					return err
+				}
+
+				intervalText, err := reader.readString("text of " + intervalField)
+				if err != nil {
+					return err
+				}
+
+				intervals = append(intervals, Interval{xmin: intervalXmin, xmax: intervalXmax, text: intervalText})
+			}
+
+			tiers = append(tiers, &IntervalTier{name: tierName, xmin: tierXmin, xmax: tierXmax, intervals: intervals})
+		case "TextTier":
+			points := make([]Point, 0, reader.capacity(tierContentCount))
+
+			for pointNum := range tierContentCount {
+				pointField := fmt.Sprintf("point %d in %s", pointNum+1, tierField)
+
+				pointValue, err := reader.readFloat("number of " + pointField)
+				if err != nil {
+					return err
+				}
+
+				pointMark, err := reader.readString("mark of " + pointField)
+				if err != nil {
+					return err
+				}
+
+				points = append(points, Point{value: pointValue, mark: pointMark})
+			}
+
+			tiers = append(tiers, &PointTier{name: tierName, xmin: tierXmin, xmax: tierXmax, points: points})
+		default:
+			return &ParseError{Field: "class of " + tierField, Cause: fmt.Errorf("unexpected tier type %s", tierType)}
+		}
+	}
+	tg.tiers = tiers
+
+	return nil
+}
+
+// WriteBinary writes to a .TextGrid file in Praat's binary format, which is faster for Praat to read than the text formats.
+// Existing files are only overwritten if overwrite is set to true.
+// If the path is a directory, the contents will be written to a file with the same name as the TextGrid, in the directory.
+func (tg *TextGrid) WriteBinary(path string, overwrite ...bool) error {
+	// default to false
+	if len(overwrite) == 0 {
+		overwrite = append(overwrite, false)
+	}
+
+	return tg.WriteBinaryWith(path, fileio.WithOverwrite(overwrite[0]))
+}
+
+// WriteBinaryWith writes to a .TextGrid file in binary format like WriteBinary, following the given write options.
+// By default, existing files are not overwritten, missing directories are created and the file is written atomically.
+func (tg *TextGrid) WriteBinaryWith(path string, options ...fileio.WriteOption) error {
+	return fileio.WriteFile(fileio.ResolvePath(path, tg.name+".TextGrid"), tg.writeBinary, options...)
+}
+
+// writeBinary writes a TextGrid to an io.Writer in binary format.
+func (tg *TextGrid) writeBinary(writer io.Writer) error {
+	var buffer bytes.Buffer
+
+	buffer.Write(binaryHeader)
+	return tg.writeBinaryBody(&buffer, writer)
+}
+
+// writeBinaryBody writes everything following the header of a TextGrid in binary format, flushing the buffer to the io.Writer as it goes.
+func (tg *TextGrid) writeBinaryBody(buffer *bytes.Buffer, writer io.Writer) error {
+	writeFloat(buffer, tg.xmin)
+	writeFloat(buffer, tg.xmax)
+
+	// the tier status is <absent> if there are no tiers
+	if tg.tiers == nil {
+		buffer.WriteByte(0)
+		_, err := buffer.WriteTo(writer)
+		return err
+	}
+	buffer.WriteByte(1)
+
+	writeInt(buffer, tg.GetSize())
+	for tierNum, tier := range tg.tiers {
+		tierField := fmt.Sprintf("tier %d", tierNum+1)
+
+		err := writeShortString(buffer, tier.GetType(), "class of "+tierField)
+		if err != nil {
+			return err
+		}
+
+		err = writeString(buffer, tier.GetName(), "name of "+tierField)
+		if err != nil {
+			return err
+		}
+
+		writeFloat(buffer, tier.GetXmin())
+		writeFloat(buffer, tier.GetXmax())
+		writeInt(buffer, tier.GetSize())
+
+		if tier.GetType() == "IntervalTier" {
+			for intervalNum, interval := range tier.GetIntervals() {
+				writeFloat(buffer, interval.xmin)
+				writeFloat(buffer, interval.xmax)
+
+				err = writeString(buffer, interval.text, fmt.Sprintf("text of interval %d in %s", intervalNum+1, tierField))
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			for pointNum, point := range tier.GetPoints() {
+				writeFloat(buffer, point.value)
+
+				err = writeString(buffer, point.mark, fmt.Sprintf("mark of point %d in %s", pointNum+1, tierField))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// flush every tier so large textgrids are not held in memory twice
+		_, err = buffer.WriteTo(writer)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := buffer.WriteTo(writer)
+	return err
+}
+
+// writeFloat writes a big endian 64 bit float.
+func writeFloat(buffer *bytes.Buffer, value float64) {
+	buffer.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(value)))
+}
+
+// writeInt writes a big endian signed 32 bit integer.
+func writeInt(buffer *bytes.Buffer, value int) {
+	buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(value))))
+}
+
+// writeShortString writes a string with an 8 bit length, or as marked UTF-16 if it is not ASCII.
+// Returns an error if the string is too long for its length to fit in 8 bits.
+func writeShortString(buffer *bytes.Buffer, value string, field string) error {
+	if isASCII(value) && len(value) < 0xFF {
+		buffer.WriteByte(byte(len(value)))
+		buffer.WriteString(value)
+		return nil
+	}
+
+	units := utf16.Encode([]rune(value))
+	if len(units) > 0xFF {
+		return fmt.Errorf("error: %s is %d characters long, when binary files can only hold %d", field, len(units), 0xFF)
+	}
+
+	buffer.WriteByte(0xFF)
+	buffer.WriteByte(byte(len(units)))
+	writeUTF16(buffer, units)
+	return nil
+}
+
+// writeString writes a string with a 16 bit length, or as marked UTF-16 if it is not ASCII.
+// Returns an error if the string is too long for its length to fit in 16 bits.
+func writeString(buffer *bytes.Buffer, value string, field string) error {
+	if isASCII(value) && len(value) < binaryUTF16Marker {
+		buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(len(value))))
+		buffer.WriteString(value)
+		return nil
+	}
+
+	units := utf16.Encode([]rune(value))
+	if len(units) > binaryUTF16Marker {
+		return fmt.Errorf("error: %s is %d characters long, when binary files can only hold %d", field, len(units), binaryUTF16Marker)
+	}
+
+	buffer.Write(binary.BigEndian.AppendUint16(nil, binaryUTF16Marker))
+	buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(len(units))))
+	writeUTF16(buffer, units)
+	return nil
+}
+
+// writeUTF16 writes big endian UTF-16 code units.
+func writeUTF16(buffer *bytes.Buffer, units []uint16) {
+	for _, unit := range units {
+		buffer.Write(binary.BigEndian.AppendUint16(nil, unit))
+	}
+}
+
+// isASCII returns true if a string only holds ASCII characters.
+func isASCII(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] >= 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
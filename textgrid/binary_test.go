@@ -0,0 +1,156 @@
+package textgrid
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadingBinaryTextgrid(t *testing.T) {
+	binaryTg, err := ReadTextgrid("examples/binary.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	textTg, err := ReadTextgrid("examples/short.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if binaryTg.GetXmin() != textTg.GetXmin() || binaryTg.GetXmax() != textTg.GetXmax() {
+		t.Errorf("expected xmin %f and xmax %f, got %f and %f", textTg.GetXmin(), textTg.GetXmax(), binaryTg.GetXmin(), binaryTg.GetXmax())
+	}
+	if !reflect.DeepEqual(binaryTg.GetTiers(), textTg.GetTiers()) {
+		t.Errorf("expected tiers %v, got %v", textTg.GetTiers(), binaryTg.GetTiers())
+	}
+	if !binaryTg.HasPointTier() {
+		t.Error("expected binary textgrid to have a point tier")
+	}
+}
+
+func TestWritingBinaryTextgrid(t *testing.T) {
+	tg, err := ReadTextgrid("examples/short.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tg.WriteBinary("examples/binary_output.TextGrid", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := os.ReadFile("examples/binary.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile("examples/binary_output.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(written, expected) {
+		t.Error("expected written binary textgrid to match examples/binary.TextGrid")
+	}
+}
+
+func TestBinaryRoundTripUnicode(t *testing.T) {
+	tg, err := ReadTextgrid("examples/polish65.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	err = tg.writeBinary(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result TextGrid
+	err = parseBinary(&result, buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(result.GetTiers(), tg.GetTiers()) {
+		t.Error("expected tiers with non-ASCII text to survive a binary round trip")
+	}
+}
+
+func TestReadingTruncatedBinaryTextgrid(t *testing.T) {
+	data, err := os.ReadFile("examples/binary.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tg TextGrid
+	err = parseBinary(&tg, data[:len(data)-3])
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected ParseError to unwrap to io.ErrUnexpectedEOF, got %v", parseError.Cause)
+	}
+	if parseError.Field != "mark of point 3 in tier 3" {
+		t.Errorf("expected field \"mark of point 3 in tier 3\", got %q", parseError.Field)
+	}
+}
+
+func TestReadingBinaryUTF16Text(t *testing.T) {
+	// a single interval with the text "łódź", laid out by hand as a marked UTF-16 string
+	data := []byte(binaryMagic + "\x08TextGrid")
+	data = append(data, 0, 0, 0, 0, 0, 0, 0, 0, 0x3F, 0xF0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1)
+	data = append(data, 12)
+	data = append(data, "IntervalTier"...)
+	data = append(data, 0, 5)
+	data = append(data, "words"...)
+	data = append(data, 0, 0, 0, 0, 0, 0, 0, 0, 0x3F, 0xF0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1)
+	data = append(data, 0, 0, 0, 0, 0, 0, 0, 0, 0x3F, 0xF0, 0, 0, 0, 0, 0, 0)
+	data = append(data, 0xFF, 0xFF, 0, 4, 0x01, 0x42, 0x00, 0xF3, 0x00, 0x64, 0x01, 0x7A)
+
+	var tg TextGrid
+	err := parseBinary(&tg, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intervals := tg.GetTiers()[0].GetIntervals()
+	if len(intervals) != 1 || intervals[0].GetText() != "łódź" || intervals[0].GetXmax() != 1 {
+		t.Errorf("expected interval [0, 1, łódź], got %v", intervals)
+	}
+}
+
+func TestWritingBinaryTextTooLong(t *testing.T) {
+	tg := TextGrid{xmax: 1, name: "long"}
+	tier := IntervalTier{name: "words", xmax: 1, intervals: []Interval{{xmax: 1, text: strings.Repeat("ł", binaryUTF16Marker+1)}}}
+	tg.tiers = append(tg.tiers, &tier)
+
+	var buffer bytes.Buffer
+	err := tg.writeBinary(&buffer)
+	if err == nil || !strings.Contains(err.Error(), "text of interval 1 in tier 1") {
+		t.Fatalf("expected error for the text of interval 1 in tier 1, got %v", err)
+	}
+
+	// ASCII text that does not fit its 16 bit length is written as UTF-16, which has the same limit
+	tier.intervals[0].text = strings.Repeat("a", binaryUTF16Marker)
+	buffer.Reset()
+	err = tg.writeBinary(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result TextGrid
+	err = parseBinary(&result, buffer.Bytes())
+	if err != nil || result.GetTiers()[0].GetIntervals()[0].GetText() != tier.intervals[0].text {
+		t.Fatalf("expected text of %d characters to survive a binary round trip, got %v", binaryUTF16Marker, err)
+	}
+
+	if err := writeShortString(&buffer, strings.Repeat("ł", 0x100), "class of tier 1"); err == nil {
+		t.Error("expected error for a class name longer than 255 characters")
+	}
+}
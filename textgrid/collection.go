@@ -0,0 +1,329 @@
+package textgrid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/vocatart/golab/fileio"
+)
+
+// ErrUnsupportedClass is wrapped by the ParseError returned when a Collection holds an object that is not a TextGrid.
+var ErrUnsupportedClass = errors.New("unsupported object class")
+
+// binaryCollectionHeader starts every binary Collection file. The class name is preceded by its length.
+var binaryCollectionHeader = []byte(binaryMagic + "\x0aCollection")
+
+// Collection structs represent a Praat Collection, which holds several named objects in a single file.
+// Praat writes a Collection when saving a selection of objects. Only TextGrid objects are supported, and each TextGrid is named after its object.
+// Objects of other classes that were skipped while reading are kept as SkippedObjects, but are not written.
+type Collection struct {
+	textgrids []TextGrid
+	skipped   []SkippedObject
+	name      string
+}
+
+// SkippedObject is an object of a Collection file that was skipped because it is not a TextGrid.
+type SkippedObject struct {
+	Class string
+	Name  string
+	Line  int
+}
+
+// GetName returns name of a Collection.
+func (collection *Collection) GetName() string {
+	return collection.name
+}
+
+// SetName sets name of a Collection.
+func (collection *Collection) SetName(name string) {
+	collection.name = name
+}
+
+// GetTextgrids returns every TextGrid of a Collection, in the order they appear in the file.
+func (collection *Collection) GetTextgrids() []TextGrid {
+	return collection.textgrids
+}
+
+// SetTextgrids sets the TextGrid slice of a Collection.
+func (collection *Collection) SetTextgrids(textgrids []TextGrid) {
+	collection.textgrids = textgrids
+}
+
+// GetTextgrid returns the first TextGrid of a Collection with the specified name. Returns false if there is none.
+func (collection *Collection) GetTextgrid(name string) (TextGrid, bool) {
+	for _, tg := range collection.textgrids {
+		if tg.name == name {
+			return tg, true
+		}
+	}
+
+	return TextGrid{}, false
+}
+
+// GetSkipped returns the objects that were skipped when reading a Collection, in the order they appear in the file.
+func (collection *Collection) GetSkipped() []SkippedObject {
+	return collection.skipped
+}
+
+// PushTextgrid adds a TextGrid to the end of a Collection. The name of the TextGrid is used as its object name.
+func (collection *Collection) PushTextgrid(tg TextGrid) {
+	collection.textgrids = append(collection.textgrids, tg)
+}
+
+// GetSize returns the amount of TextGrid entries in a Collection.
+func (collection *Collection) GetSize() int {
+	return len(collection.textgrids)
+}
+
+// ReadCollection takes a path to a Praat Collection file and reads its TextGrids into a Collection.
+// Long, short and binary Collection files are all recognized.
+// Objects that are not TextGrids are skipped in long text files, and can be found with GetSkipped.
+// Short and binary files do not label their objects or store their length, so reading stops at the first object that is not a TextGrid,
+// unless it is the last one. The TextGrids read before it are returned along with a *ParseError wrapping ErrUnsupportedClass.
+func ReadCollection(path string) (Collection, error) {
+	collection := Collection{name: filepath.Base(path)}
+
+	err := readFile(path, func(data []byte) error {
+		return parseBinaryCollection(&collection, data)
+	}, func(content *lexer) error {
+		return parseCollection(&collection, content)
+	})
+
+	return collection, err
+}
+
+// parseCollection reads the tokens of a Collection file into a Collection.
+func parseCollection(collection *Collection, content *lexer) error {
+	err := verifyHead(content, "Collection")
+	if err != nil {
+		return err
+	}
+
+	size, err := pullInt(content, "collection size")
+	if err != nil {
+		return err
+	}
+
+	for itemNum := range size {
+		itemField := fmt.Sprintf("item %d", itemNum+1)
+
+		classToken, err := pullString(content, "class of "+itemField)
+		if err != nil {
+			return err
+		}
+
+		nameToken, err := pullString(content, "name of "+itemField)
+		if err != nil {
+			return err
+		}
+
+		err = checkClass(classToken.text)
+		if err != nil {
+			// the object ends where the label of the next item starts, which only long text files have
+			skipErr := content.skipTo(fmt.Sprintf("item [%d]:", itemNum+2))
+			if errors.Is(skipErr, io.EOF) && itemNum != size-1 {
+				return newParseError(classToken, "class of "+itemField, err)
+			} else if skipErr != nil && !errors.Is(skipErr, io.EOF) {
+				return &ParseError{Line: content.line, Column: content.column + 1, Field: itemField, Cause: skipErr}
+			}
+
+			collection.skipped = append(collection.skipped, SkippedObject{Class: classToken.text, Name: nameToken.text, Line: classToken.line})
+			continue
+		}
+
+		tg := TextGrid{name: nameToken.text}
+		err = parseBody(&tg, content)
+		if err != nil {
+			return err
+		}
+
+		collection.textgrids = append(collection.textgrids, tg)
+	}
+
+	return nil
+}
+
+// parseBinaryCollection reads the contents of a binary Collection file into a Collection.
+func parseBinaryCollection(collection *Collection, data []byte) error {
+	reader, err := newBinaryReader(data, binaryCollectionHeader)
+	if err != nil {
+		return err
+	}
+
+	size, err := reader.readInt("collection size")
+	if err != nil {
+		return err
+	}
+
+	for itemNum := range size {
+		itemField := fmt.Sprintf("item %d", itemNum+1)
+
+		class, err := reader.readShortString("class of " + itemField)
+		if err != nil {
+			return err
+		}
+
+		name, err := reader.readString("name of " + itemField)
+		if err != nil {
+			return err
+		}
+
+		err = checkClass(class)
+		if err != nil {
+			return &ParseError{Field: "class of " + itemField, Cause: err}
+		}
+
+		tg := TextGrid{name: name}
+		err = reader.readBody(&tg)
+		if err != nil {
+			return err
+		}
+
+		collection.textgrids = append(collection.textgrids, tg)
+	}
+
+	return nil
+}
+
+// checkClass returns an error wrapping ErrUnsupportedClass if the class of an object is not TextGrid.
+// Praat adds a version number to the class of some objects, which is ignored.
+func checkClass(class string) error {
+	className, _, _ := strings.Cut(class, " ")
+	if className != "TextGrid" {
+		return fmt.Errorf("%w %q", ErrUnsupportedClass, class)
+	}
+
+	return nil
+}
+
+// WriteLong writes a Collection to a file in long text format.
+// Existing files are only overwritten if overwrite is set to true.
+// If the path is a directory, the contents will be written to a file with the same name as the Collection, in the directory.
+func (collection *Collection) WriteLong(path string, overwrite ...bool) error {
+	// default to false
+	if len(overwrite) == 0 {
+		overwrite = append(overwrite, false)
+	}
+
+	return collection.WriteLongWith(path, fileio.WithOverwrite(overwrite[0]))
+}
+
+// WriteLongWith writes a Collection to a file in long text format like WriteLong, following the given write options.
+func (collection *Collection) WriteLongWith(path string, options ...fileio.WriteOption) error {
+	return fileio.WriteFile(fileio.ResolvePath(path, collection.name+".Collection"), collection.writeLong, options...)
+}
+
+// writeLong writes a Collection to an io.Writer in long text format, indenting each TextGrid inside its item.
+func (collection *Collection) writeLong(writer io.Writer) error {
+	_, err := fmt.Fprintf(writer, "File type = \"ooTextFile\"\nObject class = \"Collection\"\n\nsize = %d\nitem []:\n", collection.GetSize())
+	if err != nil {
+		return err
+	}
+
+	for itemNum, tg := range collection.textgrids {
+		_, err = fmt.Fprintf(writer, "\titem [%d]:\n\t\tclass = \"TextGrid\"\n\t\tname = %s\n", itemNum+1, quote(tg.name))
+		if err != nil {
+			return err
+		}
+
+		// the textgrid is indented inside its item, apart from the lines of multi-line strings
+		indented := &indentWriter{writer: writer, indent: "\t\t", lineStart: true}
+		err = tg.writeLongBody(indented)
+		if err != nil {
+			return err
+		}
+
+		if !indented.lineStart {
+			_, err = io.WriteString(writer, "\n")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// WriteBinary writes a Collection to a file in Praat's binary format.
+// Existing files are only overwritten if overwrite is set to true.
+// If the path is a directory, the contents will be written to a file with the same name as the Collection, in the directory.
+func (collection *Collection) WriteBinary(path string, overwrite ...bool) error {
+	// default to false
+	if len(overwrite) == 0 {
+		overwrite = append(overwrite, false)
+	}
+
+	return collection.WriteBinaryWith(path, fileio.WithOverwrite(overwrite[0]))
+}
+
+// WriteBinaryWith writes a Collection to a file in binary format like WriteBinary, following the given write options.
+func (collection *Collection) WriteBinaryWith(path string, options ...fileio.WriteOption) error {
+	return fileio.WriteFile(fileio.ResolvePath(path, collection.name+".Collection"), collection.writeBinary, options...)
+}
+
+// writeBinary writes a Collection to an io.Writer in binary format.
+func (collection *Collection) writeBinary(writer io.Writer) error {
+	var buffer bytes.Buffer
+
+	buffer.Write(binaryCollectionHeader)
+	writeInt(&buffer, collection.GetSize())
+
+	for itemNum, tg := range collection.textgrids {
+		itemField := fmt.Sprintf("item %d", itemNum+1)
+
+		err := writeShortString(&buffer, "TextGrid", "class of "+itemField)
+		if err != nil {
+			return err
+		}
+
+		err = writeString(&buffer, tg.name, "name of "+itemField)
+		if err != nil {
+			return err
+		}
+
+		err = tg.writeBinaryBody(&buffer, writer)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := buffer.WriteTo(writer)
+	return err
+}
+
+// indentWriter indents every line written to it, except for lines continuing a string.
+// Doubled quotes inside strings switch the state twice, so they are handled without looking ahead.
+type indentWriter struct {
+	writer    io.Writer
+	indent    string
+	lineStart bool
+	inString  bool
+}
+
+// Write writes bytes to the underlying io.Writer, adding the indent at the start of each line.
+func (indented *indentWriter) Write(data []byte) (int, error) {
+	var output []byte
+
+	for _, current := range data {
+		if indented.lineStart && !indented.inString {
+			output = append(output, indented.indent...)
+		}
+
+		output = append(output, current)
+		indented.lineStart = current == '\n'
+		if current == '"' {
+			indented.inString = !indented.inString
+		}
+	}
+
+	_, err := indented.writer.Write(output)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
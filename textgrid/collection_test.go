@@ -0,0 +1,148 @@
+package textgrid
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadingCollection(t *testing.T) {
+	collection, err := ReadCollection("examples/textgrids.Collection")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if collection.GetSize() != 2 {
+		t.Fatalf("expected 2 textgrids, got %d", collection.GetSize())
+	}
+
+	expected, err := ReadTextgrid("examples/long.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, exists := collection.GetTextgrid("second")
+	if !exists {
+		t.Fatal("expected a textgrid named \"second\"")
+	}
+	if second.GetXmax() != expected.GetXmax() || !reflect.DeepEqual(second.GetTiers(), expected.GetTiers()) {
+		t.Errorf("expected textgrid \"second\" to match examples/long.TextGrid")
+	}
+}
+
+func TestReadingShortCollection(t *testing.T) {
+	collection, err := ReadCollection("examples/short.Collection")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if collection.GetSize() != 1 || collection.GetTextgrids()[0].GetName() != "short" {
+		t.Fatalf("expected a single textgrid named \"short\", got %d textgrids", collection.GetSize())
+	}
+	if collection.GetTextgrids()[0].GetSize() != 3 {
+		t.Errorf("expected 3 tiers, got %d", collection.GetTextgrids()[0].GetSize())
+	}
+}
+
+func TestReadingUnsupportedCollection(t *testing.T) {
+	collection, err := ReadCollection("examples/unsupported.Collection")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the sound in between the textgrids is skipped
+	if collection.GetSize() != 2 {
+		t.Fatalf("expected 2 textgrids, got %d", collection.GetSize())
+	}
+	words, exists := collection.GetTextgrid("words")
+	if !exists || words.GetSize() != 1 {
+		t.Errorf("expected a textgrid named \"words\" with 1 tier after the skipped object")
+	}
+
+	expected := []SkippedObject{{Class: "Sound 2", Name: "speech", Line: 62}}
+	if !reflect.DeepEqual(collection.GetSkipped(), expected) {
+		t.Errorf("expected skipped objects %v, got %v", expected, collection.GetSkipped())
+	}
+}
+
+func TestReadingUnsupportedShortCollection(t *testing.T) {
+	content := "File type = \"ooTextFile\"\nObject class = \"Collection\"\n\n2\n\"Sound 2\"\n\"speech\"\n0\n1\n\"TextGrid\"\n\"words\"\n0\n1\n<absent>\n"
+
+	// short files have no labels to find the end of an object by
+	var collection Collection
+	err := parseCollection(&collection, newLexer(strings.NewReader(content)))
+	if !errors.Is(err, ErrUnsupportedClass) {
+		t.Fatalf("expected error wrapping ErrUnsupportedClass, got %v", err)
+	}
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected ParseError, got %T", err)
+	}
+	if parseError.Field != "class of item 1" || parseError.Line != 5 {
+		t.Errorf("expected \"class of item 1\" on line 5, got %q on line %d", parseError.Field, parseError.Line)
+	}
+}
+
+func TestCollectionRoundTrip(t *testing.T) {
+	collection, err := ReadCollection("examples/textgrids.Collection")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = collection.WriteLong("examples/collection_output.Collection", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = collection.WriteBinary("examples/binary_output.Collection", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"examples/collection_output.Collection", "examples/binary_output.Collection"} {
+		result, err := ReadCollection(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(result.GetTextgrids(), collection.GetTextgrids()) {
+			t.Errorf("expected %s to hold the same textgrids", path)
+		}
+	}
+}
+
+func TestReadingTextgridAsCollection(t *testing.T) {
+	_, err := ReadCollection("examples/long.TextGrid")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) || parseError.Field != "object class" {
+		t.Fatalf("expected ParseError for the object class, got %v", err)
+	}
+}
+
+func TestCollectionMultilineStrings(t *testing.T) {
+	tg, err := ReadTextgrid("examples/conformance.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collection := Collection{name: "conformance"}
+	collection.PushTextgrid(tg)
+
+	err = collection.WriteLong("examples/conformance_output.Collection", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ReadCollection("examples/conformance_output.Collection")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lines continuing a string must not be indented
+	if !reflect.DeepEqual(result.GetTextgrids(), collection.GetTextgrids()) {
+		t.Errorf("expected %v, got %v", collection.GetTextgrids(), result.GetTextgrids())
+	}
+}
@@ -0,0 +1,42 @@
+package textgrid
+
+import "fmt"
+
+// ParseError describes a problem found while reading a TextGrid file, and where it was found.
+// Line and Column start at 1, and are 0 if the file ended before the value could be read.
+// Field names the value that could not be read, such as "xmin of interval 2 in tier 1".
+// Use errors.As to retrieve a ParseError from the error returned by ReadTextgrid.
+type ParseError struct {
+	Path   string
+	Line   int
+	Column int
+	Field  string
+	Cause  error
+}
+
+// Error returns a readable description of a ParseError, starting with its position as path:line:column.
+func (parseError *ParseError) Error() string {
+	location := parseError.Path
+	if parseError.Line > 0 {
+		location += fmt.Sprintf(":%d", parseError.Line)
+		if parseError.Column > 0 {
+			location += fmt.Sprintf(":%d", parseError.Column)
+		}
+	}
+
+	if parseError.Field == "" {
+		return fmt.Sprintf("error: malformed textgrid %s: %v", location, parseError.Cause)
+	}
+
+	return fmt.Sprintf("error: malformed %s in %s: %v", parseError.Field, location, parseError.Cause)
+}
+
+// Unwrap returns the cause of a ParseError.
+func (parseError *ParseError) Unwrap() error {
+	return parseError.Cause
+}
+
+// newParseError creates a ParseError at the position of a token. The path is set once the error reaches ReadTextgrid.
+func newParseError(tok token, field string, cause error) *ParseError {
+	return &ParseError{Line: tok.line, Column: tok.column, Field: field, Cause: cause}
+}
@@ -0,0 +1,49 @@
+package textgrid
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	_, err := ReadTextgrid("examples/malformed.TextGrid")
+	if err == nil {
+		t.Fatal("expected error for malformed textgrid")
+	}
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected ParseError, got %T", err)
+	}
+
+	if parseError.Path != "examples/malformed.TextGrid" || parseError.Line != 21 || parseError.Column != 20 {
+		t.Errorf("expected examples/malformed.TextGrid:21:20, got %s:%d:%d", parseError.Path, parseError.Line, parseError.Column)
+	}
+	if parseError.Field != "xmax of interval 2 in tier 1" {
+		t.Errorf("expected field \"xmax of interval 2 in tier 1\", got %q", parseError.Field)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected ParseError to unwrap to strconv.ErrSyntax, got %v", parseError.Cause)
+	}
+}
+
+func TestParseErrorTruncated(t *testing.T) {
+	_, err := ReadTextgrid("examples/truncated.TextGrid")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected unexpected end of file, got %v", parseError.Cause)
+	}
+	if parseError.Line != 0 || parseError.Field != "xmin of interval 1 in tier 1" {
+		t.Errorf("expected xmin of interval 1 in tier 1 without a line, got %q at line %d", parseError.Field, parseError.Line)
+	}
+	if parseError.Error() != "error: malformed xmin of interval 1 in tier 1 in examples/truncated.TextGrid: unexpected EOF" {
+		t.Errorf("malformed error string, got %q", parseError.Error())
+	}
+}
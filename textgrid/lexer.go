@@ -0,0 +1,304 @@
+package textgrid
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// undefinedNumber is how Praat writes a number that is undefined, which is read as NaN.
+const undefinedNumber = "--undefined--"
+
+// undefinedSuffix follows the first character of undefinedNumber.
+const undefinedSuffix = "-undefined--"
+
+// lexerBufferSize is the size of the buffer of a lexer.
+const lexerBufferSize = 64 * 1024
+
+// tokenKind is the type of value held by a token.
+type tokenKind uint8
+
+const (
+	// numberToken is an integer or decimal number, which may have a sign and an exponent.
+	numberToken tokenKind = iota
+	// stringToken is text in between double quotes.
+	stringToken
+	// flagToken is text in between angle brackets, such as <exists>.
+	flagToken
+)
+
+// token is a single value of a TextGrid file, with the line and column it starts at.
+// Strings and flags hold their text without the quotes or angle brackets around them.
+type token struct {
+	kind   tokenKind
+	text   string
+	line   int
+	column int
+}
+
+// lexer reads the tokens of a text TextGrid file one at a time from an io.Reader, keeping track of their position.
+// Any TextGrid is read like a "short" TextGrid, skipping everything in between the values, including the indices of items, intervals and points.
+// It follows the grammar of Praat text files, where comments start with ! and run until the end of the line.
+type lexer struct {
+	reader *bufio.Reader
+	line   int
+	column int
+	buffer []byte
+}
+
+// newLexer creates a lexer reading from an io.Reader, which should already be decoded into UTF-8.
+func newLexer(reader io.Reader) *lexer {
+	return &lexer{reader: bufio.NewReaderSize(reader, lexerBufferSize), line: 1}
+}
+
+// next reads the next token. Returns io.EOF once there are no tokens left.
+// A string or flag that is never closed returns an error wrapping io.ErrUnexpectedEOF, along with the position it starts at.
+func (lex *lexer) next() (token, error) {
+	for {
+		current, err := lex.readByte()
+		if err != nil {
+			return token{}, err
+		}
+
+		line, column := lex.line, lex.column
+		switch {
+		case isDigit(current) || (isSign(current) && lex.startsNumber()) || (current == '.' && lex.startsFraction()):
+			lex.buffer = append(lex.buffer[:0], current)
+			return lex.readNumber(line, column), nil
+		case current == '-' && bytes.Equal(lex.peek(len(undefinedSuffix)), []byte(undefinedSuffix)):
+			// Praat writes undefined numbers as --undefined--
+			lex.skip(len(undefinedSuffix))
+			return token{kind: numberToken, text: undefinedNumber, line: line, column: column}, nil
+		case current == '!':
+			// comments run until the end of the line
+			lex.skipLine()
+		case current == '"':
+			return lex.readString(line, column)
+		case current == '<':
+			return lex.readFlag(line, column)
+		case current == '[':
+			// indices like [1] are skipped, but the digits are still read as a number if the bracket is never closed
+			next, err := lex.reader.Peek(1)
+			if err != nil || !isDigit(next[0]) {
+				continue
+			}
+
+			line, column = lex.line, lex.column+1
+			lex.buffer = lex.buffer[:0]
+			lex.readDigits()
+
+			next, err = lex.reader.Peek(1)
+			if err == nil && next[0] == ']' {
+				_, _ = lex.readByte()
+				continue
+			}
+
+			return lex.readNumber(line, column), nil
+		}
+	}
+}
+
+// readNumber finishes reading a number whose first character is in the buffer.
+// Numbers can have a sign, a fraction and an exponent, such as -1.5e-05.
+func (lex *lexer) readNumber(line int, column int) token {
+	lex.readDigits()
+
+	// a decimal point is only part of the number if a digit follows it
+	if next := lex.peek(2); bytes.IndexByte(lex.buffer, '.') == -1 && len(next) == 2 && next[0] == '.' && isDigit(next[1]) {
+		point, _ := lex.readByte()
+		lex.buffer = append(lex.buffer, point)
+		lex.readDigits()
+	}
+
+	// an exponent is only part of the number if a digit follows it, after an optional sign
+	next := lex.peek(3)
+	if len(next) >= 2 && (next[0] == 'e' || next[0] == 'E') && (isDigit(next[1]) || (len(next) == 3 && isSign(next[1]) && isDigit(next[2]))) {
+		exponent, _ := lex.readByte()
+		lex.buffer = append(lex.buffer, exponent)
+
+		if isSign(next[1]) {
+			sign, _ := lex.readByte()
+			lex.buffer = append(lex.buffer, sign)
+		}
+		lex.readDigits()
+	}
+
+	return token{kind: numberToken, text: string(lex.buffer), line: line, column: column}
+}
+
+// startsNumber returns true if the sign just read is followed by a number.
+func (lex *lexer) startsNumber() bool {
+	next := lex.peek(2)
+	return len(next) > 0 && (isDigit(next[0]) || (len(next) == 2 && next[0] == '.' && isDigit(next[1])))
+}
+
+// startsFraction returns true if the decimal point just read is followed by a digit.
+func (lex *lexer) startsFraction() bool {
+	next := lex.peek(1)
+	return len(next) == 1 && isDigit(next[0])
+}
+
+// readDigits adds every following digit to the buffer.
+func (lex *lexer) readDigits() {
+	for {
+		next, err := lex.reader.Peek(1)
+		if err != nil || !isDigit(next[0]) {
+			return
+		}
+
+		digit, _ := lex.readByte()
+		lex.buffer = append(lex.buffer, digit)
+	}
+}
+
+// readString reads the rest of a string after its opening quote. Strings can span several lines, and a doubled quote is a quote inside the string.
+func (lex *lexer) readString(line int, column int) (token, error) {
+	lex.buffer = lex.buffer[:0]
+
+	for {
+		err := lex.readUntil('"')
+		if errors.Is(err, io.EOF) {
+			return token{kind: stringToken, line: line, column: column}, fmt.Errorf("unterminated string: %w", io.ErrUnexpectedEOF)
+		} else if err != nil {
+			return token{}, err
+		}
+
+		// the quote found is either the end of the string or the first of a doubled quote, which is kept
+		next := lex.peek(1)
+		if len(next) == 0 || next[0] != '"' {
+			lex.buffer = lex.buffer[:len(lex.buffer)-1]
+			return token{kind: stringToken, text: string(lex.buffer), line: line, column: column}, nil
+		}
+		lex.skip(1)
+	}
+}
+
+// readFlag reads the rest of a flag after its opening angle bracket.
+func (lex *lexer) readFlag(line int, column int) (token, error) {
+	lex.buffer = lex.buffer[:0]
+
+	err := lex.readUntil('>')
+	if errors.Is(err, io.EOF) {
+		return token{kind: flagToken, line: line, column: column}, fmt.Errorf("unterminated flag: %w", io.ErrUnexpectedEOF)
+	} else if err != nil {
+		return token{}, err
+	}
+
+	lex.buffer = lex.buffer[:len(lex.buffer)-1]
+	return token{kind: flagToken, text: string(lex.buffer), line: line, column: column}, nil
+}
+
+// readUntil adds everything up to and including a delimiter to the buffer.
+// If the file ends first, everything read so far is added, and io.EOF is returned.
+func (lex *lexer) readUntil(delimiter byte) error {
+	for {
+		chunk, err := lex.reader.ReadSlice(delimiter)
+		lex.advance(chunk)
+		lex.buffer = append(lex.buffer, chunk...)
+
+		// the value is longer than the buffer, so keep reading
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+	}
+}
+
+// skipLine skips everything up to and including the end of the line.
+func (lex *lexer) skipLine() {
+	for {
+		chunk, err := lex.reader.ReadSlice('\n')
+		lex.advance(chunk)
+
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return
+		}
+	}
+}
+
+// skipTo skips everything up to and including the next label at the start of a line, apart from its indent.
+// Strings and comments are skipped whole, so a label inside them is not found. Returns io.EOF if the file ends first.
+func (lex *lexer) skipTo(label string) error {
+	lineStart := true
+
+	for {
+		current, err := lex.readByte()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case current == '"':
+			_, err = lex.readString(lex.line, lex.column)
+			if err != nil {
+				return err
+			}
+			lineStart = false
+		case current == '!':
+			lex.skipLine()
+			lineStart = true
+		case current == '\n':
+			lineStart = true
+		case current == ' ' || current == '\t' || current == '\r':
+			// indents keep the start of the line
+		case lineStart && current == label[0] && bytes.Equal(lex.peek(len(label)-1), []byte(label[1:])):
+			lex.skip(len(label) - 1)
+			return nil
+		default:
+			lineStart = false
+		}
+	}
+}
+
+// peek returns up to count of the following bytes without reading them. Fewer bytes are returned at the end of the file.
+func (lex *lexer) peek(count int) []byte {
+	next, _ := lex.reader.Peek(count)
+	return next
+}
+
+// skip reads a number of bytes that are already known from peek.
+func (lex *lexer) skip(count int) {
+	for range count {
+		_, _ = lex.readByte()
+	}
+}
+
+// readByte reads a single byte, moving the position past it.
+func (lex *lexer) readByte() (byte, error) {
+	current, err := lex.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	lex.move(current)
+	return current, nil
+}
+
+// advance moves the position past every byte of a chunk.
+func (lex *lexer) advance(chunk []byte) {
+	for _, current := range chunk {
+		lex.move(current)
+	}
+}
+
+// move moves the position past a single byte. Columns count characters, so continuation bytes of UTF-8 characters are not counted.
+func (lex *lexer) move(current byte) {
+	switch {
+	case current == '\n':
+		lex.line++
+		lex.column = 0
+	case current&0xC0 != 0x80:
+		lex.column++
+	}
+}
+
+// isSign returns true if a byte is a plus or minus sign.
+func isSign(current byte) bool {
+	return current == '-' || current == '+'
+}
+
+// isDigit returns true if a byte is an ASCII digit.
+func isDigit(current byte) bool {
+	return current >= '0' && current <= '9'
+}
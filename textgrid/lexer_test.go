@@ -0,0 +1,371 @@
+package textgrid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/TomOnTime/utfutil"
+	"github.com/saintfish/chardet"
+)
+
+func TestLexerPositions(t *testing.T) {
+	content := newLexer(strings.NewReader("xmin = 0 \nitem [1]:\n  \"a\" <exists>"))
+
+	expected := []token{
+		{kind: numberToken, text: "0", line: 1, column: 8},
+		{kind: stringToken, text: "a", line: 3, column: 3},
+		{kind: flagToken, text: "exists", line: 3, column: 7},
+	}
+	for _, want := range expected {
+		tok, err := content.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok != want {
+			t.Errorf("expected token %v, got %v", want, tok)
+		}
+	}
+
+	if _, err := content.next(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last token, got %v", err)
+	}
+}
+
+func TestLexerValues(t *testing.T) {
+	content := newLexer(strings.NewReader("[12 3. 4.5 \"zająwszy\nłódź\" [7] \"x\"8"))
+
+	expected := []token{
+		{kind: numberToken, text: "12", line: 1, column: 2},
+		{kind: numberToken, text: "3", line: 1, column: 5},
+		{kind: numberToken, text: "4.5", line: 1, column: 8},
+		{kind: stringToken, text: "zająwszy\nłódź", line: 1, column: 12},
+		{kind: stringToken, text: "x", line: 2, column: 11},
+		{kind: numberToken, text: "8", line: 2, column: 14},
+	}
+	for _, want := range expected {
+		tok, err := content.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok != want {
+			t.Errorf("expected token %v, got %v", want, tok)
+		}
+	}
+}
+
+func TestLexerLongString(t *testing.T) {
+	text := strings.Repeat("a", lexerBufferSize*2)
+	content := newLexer(strings.NewReader("\"" + text + "\" 1"))
+
+	tok, err := content.next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.text != text {
+		t.Errorf("expected a string of %d characters, got %d", len(text), len(tok.text))
+	}
+
+	tok, err = content.next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.column != lexerBufferSize*2+4 {
+		t.Errorf("expected column %d, got %d", lexerBufferSize*2+4, tok.column)
+	}
+}
+
+func TestLexerUnterminatedString(t *testing.T) {
+	content := newLexer(strings.NewReader("0\n  \"open"))
+
+	_, _ = content.next()
+	_, err := popToken(content, "text of interval 1 in tier 1")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if parseError.Line != 2 || parseError.Column != 3 || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected unexpected end of file at 2:3, got %v", err)
+	}
+}
+
+func TestLexerUnterminatedFlag(t *testing.T) {
+	content := newLexer(strings.NewReader("0\n  <exists"))
+
+	_, _ = content.next()
+	_, err := popToken(content, "tier status")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if parseError.Line != 2 || parseError.Column != 3 || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected unexpected end of file at 2:3, got %v", err)
+	}
+}
+
+func TestInvalidUTF8AfterFirstBuffer(t *testing.T) {
+	data, err := os.ReadFile("examples/long.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a long first label pushes the invalid byte in the last label past the first buffer of the lexer,
+	// and starts with a UTF-8 character so the file is not read as Latin-1
+	padding := "ł" + strings.Repeat("a", lexerBufferSize*2)
+	data = bytes.Replace(data, []byte("text = \"1_label1\""), []byte("text = \""+padding+"\""), 1)
+	if len(data) < lexerBufferSize*2 {
+		t.Fatal("expected padding to be added to the first label")
+	}
+	index := bytes.LastIndex(data, []byte("text = \""))
+	data = append(data[:index+8:index+8], append([]byte{0xE9}, data[index+8:]...)...)
+
+	_, err = ParseTextgrid(bytes.NewReader(data), "latin1")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) || !strings.Contains(err.Error(), "invalid UTF-8") {
+		t.Fatalf("expected ParseError for invalid UTF-8, got %v", err)
+	}
+
+	line := bytes.Count(data[:index], []byte("\n")) + 1
+	column := index - bytes.LastIndexByte(data[:index], '\n') + 8
+	if parseError.Line != line || parseError.Column != column {
+		t.Errorf("expected invalid UTF-8 at %d:%d, got %d:%d", line, column, parseError.Line, parseError.Column)
+	}
+}
+
+func TestUTF8ReaderSplitCharacters(t *testing.T) {
+	text := strings.Repeat("zająwszy łódź ", 1000)
+
+	// characters split between reads are passed on whole
+	validated, err := io.ReadAll(&utf8Reader{reader: iotest.OneByteReader(strings.NewReader(text))})
+	if err != nil || string(validated) != text {
+		t.Fatalf("expected text to pass through unchanged, got %v", err)
+	}
+
+	_, err = io.ReadAll(&utf8Reader{reader: strings.NewReader("ł\xC5")})
+	if err == nil || err.Error() != "invalid UTF-8 at byte 2" {
+		t.Fatalf("expected invalid UTF-8 at byte 2 for a character cut off by the end of the file, got %v", err)
+	}
+
+	// text that is only ASCII before its first invalid byte is read as Latin-1
+	validated, err = io.ReadAll(&utf8Reader{reader: iotest.OneByteReader(strings.NewReader("caf\xE9 \xC5"))})
+	if err != nil || string(validated) != "café Å" {
+		t.Fatalf("expected Latin-1 text café Å, got %q and %v", validated, err)
+	}
+}
+
+func TestLexerSkipTo(t *testing.T) {
+	content := newLexer(strings.NewReader("text = \"\nitem [2]:\" ! item [2]:\n  x item [2]:\n  item [2]:\n  5"))
+
+	// labels inside strings, comments or after other text on a line are not found
+	err := content.skipTo("item [2]:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := content.next()
+	if err != nil || next.text != "5" || next.line != 5 {
+		t.Fatalf("expected number 5 on line 5, got %q on line %d and %v", next.text, next.line, err)
+	}
+
+	if err = content.skipTo("item [3]:"); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF for a missing label, got %v", err)
+	}
+}
+
+// legacyTokenize is the whole-file regex tokenizer the lexer replaced, kept to compare their performance.
+func legacyTokenize(data []byte) []token {
+	tgString := string(data)
+	textgridRegex := regexp.MustCompile(`\[\d+]|\d+(\.\d+)?|"[^"]*"|<[^>]*`)
+
+	var tokens []token
+	line, column, offset := 1, 1, 0
+
+	for _, match := range textgridRegex.FindAllStringIndex(tgString, -1) {
+		for _, character := range tgString[offset:match[0]] {
+			if character == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		offset = match[0]
+
+		text := tgString[match[0]:match[1]]
+		if strings.HasPrefix(text, "[") {
+			continue
+		}
+
+		// values were unquoted with a regex compiled on every call
+		if strings.HasPrefix(text, "\"") {
+			text = regexp.MustCompile(`^"(.*)"$`).ReplaceAllString(text, `$1`)
+		}
+
+		tokens = append(tokens, token{text: text, line: line, column: column})
+	}
+
+	return tokens
+}
+
+// getEncoding detects the encoding of the whole file, as the legacy tokenizer did before reading it.
+func getEncoding(data []byte) (string, error) {
+	detected, err := chardet.NewTextDetector().DetectBest(data)
+	if err != nil {
+		return "", err
+	}
+
+	return detected.Charset, nil
+}
+
+// benchmarkTextgrid creates a long format TextGrid with a number of intervals in each of two tiers.
+func benchmarkTextgrid(b *testing.B, intervals int) []byte {
+	tg := TextGrid{xmax: float64(intervals), name: "benchmark"}
+
+	for tierNum := range 2 {
+		tier := IntervalTier{name: fmt.Sprintf("tier%d", tierNum), xmax: float64(intervals)}
+		for i := range intervals {
+			tier.intervals = append(tier.intervals, Interval{xmin: float64(i), xmax: float64(i + 1), text: fmt.Sprintf("label%d", i)})
+		}
+		tg.tiers = append(tg.tiers, &tier)
+	}
+
+	var buffer bytes.Buffer
+	err := tg.writeLong(&buffer)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func BenchmarkLegacyTokenizer(b *testing.B) {
+	data := benchmarkTextgrid(b, 50000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		decoded, err := io.ReadAll(utfutil.BytesReader(data, utfutil.UTF8))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := getEncoding(decoded); err != nil {
+			b.Fatal(err)
+		}
+
+		legacyTokenize(decoded)
+	}
+}
+
+func BenchmarkLexer(b *testing.B) {
+	data := benchmarkTextgrid(b, 50000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		content, err := newContentLexer(bytes.NewReader(data), "benchmark")
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for {
+			_, err := content.next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkParseContent(b *testing.B) {
+	data := benchmarkTextgrid(b, 50000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		content, err := newContentLexer(bytes.NewReader(data), "benchmark")
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var tg TextGrid
+		if err := parseContent(&tg, content); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParsingTextgridFromReader(t *testing.T) {
+	file, err := os.Open("examples/long.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tg, err := ParseTextgrid(file, "long")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := ReadTextgrid("examples/long.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tg.GetName() != "long" || !reflect.DeepEqual(tg.GetTiers(), expected.GetTiers()) {
+		t.Errorf("expected textgrid \"long\" to match examples/long.TextGrid")
+	}
+
+	_, err = ParseTextgrid(strings.NewReader("File type = \"ooTextFile\"\nObject class = \"TextGrid\"\n\nxmin = 0\n"), "stdin")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) || parseError.Path != "stdin" || parseError.Field != "xmax" {
+		t.Errorf("expected ParseError for xmax in stdin, got %v", err)
+	}
+}
+
+func TestLexerPraatGrammar(t *testing.T) {
+	content := newLexer(strings.NewReader("-1.5e-05 +2 -.5 .25 1E3 2e x-3 ! skipped \"comment\" 42\n\"a \"\"b\"\"\" \"\"\"\" --undefined-- - 7"))
+
+	expected := []token{
+		{kind: numberToken, text: "-1.5e-05", line: 1, column: 1},
+		{kind: numberToken, text: "+2", line: 1, column: 10},
+		{kind: numberToken, text: "-.5", line: 1, column: 13},
+		{kind: numberToken, text: ".25", line: 1, column: 17},
+		{kind: numberToken, text: "1E3", line: 1, column: 21},
+		{kind: numberToken, text: "2", line: 1, column: 25},
+		{kind: numberToken, text: "-3", line: 1, column: 29},
+		{kind: stringToken, text: "a \"b\"", line: 2, column: 1},
+		{kind: stringToken, text: "\"", line: 2, column: 11},
+		{kind: numberToken, text: "--undefined--", line: 2, column: 16},
+		{kind: numberToken, text: "7", line: 2, column: 32},
+	}
+	for _, want := range expected {
+		tok, err := content.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok != want {
+			t.Errorf("expected token %v, got %v", want, tok)
+		}
+	}
+
+	if _, err := content.next(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last token, got %v", err)
+	}
+}
@@ -1,17 +1,23 @@
 package textgrid
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/TomOnTime/utfutil"
-	"github.com/gammazero/deque"
-	"github.com/saintfish/chardet"
+	"github.com/vocatart/golab/fileio"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
 )
 
 // TextGrid structs represent a Praat TextGrid.
@@ -124,53 +130,200 @@ func (tg *TextGrid) GetSize() int {
 }
 
 // ReadTextgrid takes a path to a .TextGrid file and reads its contents into a TextGrid.
+// Long, short and binary TextGrid files are all recognized.
+// Problems with the contents of the file are returned as a *ParseError, holding the line and column they were found at.
 func ReadTextgrid(path string) (TextGrid, error) {
 	var tg = TextGrid{}
-	tgDeque := new(deque.Deque[string])
 
 	// grab the name element from the path
 	tg.name = filepath.Base(path)
 
+	err := readFile(path, func(data []byte) error {
+		return parseBinary(&tg, data)
+	}, func(content *lexer) error {
+		return parseContent(&tg, content)
+	})
+
+	return tg, err
+}
+
+// ParseTextgrid reads the contents of a TextGrid file from an io.Reader into a TextGrid with the given name.
+// The contents are read one token at a time, so even very long TextGrids are never held in memory as text.
+// Problems with the contents are returned as a *ParseError, with the name as its path.
+func ParseTextgrid(reader io.Reader, name string) (TextGrid, error) {
+	var tg = TextGrid{name: name}
+
+	err := readContent(reader, name, func(data []byte) error {
+		return parseBinary(&tg, data)
+	}, func(content *lexer) error {
+		return parseContent(&tg, content)
+	})
+
+	return tg, err
+}
+
+// readFile opens a Praat file and reads it with readContent.
+func readFile(path string, readBinary func([]byte) error, readText func(*lexer) error) error {
 	// check if the file exists
-	// TextGrid files are USUALLY UTF-8, UTF-16, or ASCII.
-	tgData, err := utfutil.ReadFile(path, utfutil.UTF8)
+	file, err := os.Open(path)
 	if err != nil {
-		return tg, err
+		return err
+	}
+	defer file.Close()
+
+	return readContent(file, path, readBinary, readText)
+}
+
+// readContent passes the contents of a Praat file to readBinary if it is a binary file, or a lexer over its tokens to readText otherwise.
+// Errors point to the path that was given.
+func readContent(input io.Reader, path string, readBinary func([]byte) error, readText func(*lexer) error) error {
+	reader := bufio.NewReader(input)
+
+	// binary files are read before any text decoding can change their bytes
+	magic, _ := reader.Peek(len(binaryMagic))
+	if isBinary(magic) {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		return withPath(readBinary(data), path)
 	}
 
-	// if somehow utf-util finds something else, error out
-	// chardet will sometimes read ASCII as ISO-8859-1, which go will always interpret correctly when casting its byte slice to a string.
-	retrievedEncoding, err := getEncoding(tgData)
+	content, err := newContentLexer(reader, filepath.Base(path))
 	if err != nil {
-		return tg, fmt.Errorf("error: error parsing file encoding for %s:\n %s", tg.name, err.Error())
+		return err
+	}
+
+	return withPath(readText(content), path)
+}
+
+// newContentLexer creates a lexer reading a text file, decoding it into UTF-8 if it is UTF-16.
+// Files without a UTF-16 byte order mark are checked to be UTF-8 as they are read, so the file never has to be held in memory.
+// Files that turn out not to be UTF-8 are read as Latin-1, see utf8Reader.
+func newContentLexer(reader io.Reader, name string) (*lexer, error) {
+	buffered := bufio.NewReader(reader)
+
+	// TextGrid files are USUALLY UTF-8, UTF-16, or ASCII.
+	mark, err := buffered.Peek(len(utf8ByteOrderMark))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error: cannot read %s: %w", name, err)
+	}
+
+	if bytes.HasPrefix(mark, []byte{0xFF, 0xFE}) || bytes.HasPrefix(mark, []byte{0xFE, 0xFF}) {
+		return newLexer(utfutil.NewReader(buffered, utfutil.UTF8)), nil
+	}
+
+	if bytes.HasPrefix(mark, utf8ByteOrderMark) {
+		_, _ = buffered.Discard(len(utf8ByteOrderMark))
+	}
+
+	return newLexer(&utf8Reader{reader: buffered}), nil
+}
+
+// utf8ByteOrderMark starts UTF-8 files written by some Windows editors.
+var utf8ByteOrderMark = []byte{0xEF, 0xBB, 0xBF}
+
+// utf8Reader passes on the bytes of an io.Reader as long as they are valid UTF-8.
+// A file that is only ASCII up to its first invalid byte is read as Latin-1 from there on, like the files Praat writes when it is set to try ISO Latin-1.
+// Otherwise, the valid bytes before the invalid one are still passed on, and the error is returned by the next read.
+type utf8Reader struct {
+	reader    io.Reader
+	pending   []byte
+	offset    int64
+	multibyte bool
+	latin1    io.Reader
+	err       error
+}
+
+// Read reads valid UTF-8 into data. A character split between two reads is held back until the rest of it is read.
+func (validator *utf8Reader) Read(data []byte) (int, error) {
+	if validator.err != nil {
+		return 0, validator.err
+	}
+	if validator.latin1 != nil {
+		return validator.latin1.Read(data)
+	}
+
+	// the start of a split character is passed on once it is complete
+	count := copy(data, validator.pending)
+	validator.pending = validator.pending[count:]
+
+	var err error
+	if len(validator.pending) == 0 {
+		var read int
+		read, err = validator.reader.Read(data[count:])
+		count += read
 	}
 
-	// check encoding
-	if retrievedEncoding != "UTF-8" && retrievedEncoding != "ISO-8859-1" {
-		return tg, fmt.Errorf("error: encoding out of scope, recieved %q encoding for %q", retrievedEncoding, tg.name)
+	// hold back a character that may be completed by the next read
+	complete := count
+	if err == nil {
+		for start := count - 1; start >= max(0, count-utf8.UTFMax+1); start-- {
+			if utf8.RuneStart(data[start]) {
+				if !utf8.FullRune(data[start:count]) {
+					complete = start
+				}
+				break
+			}
+		}
+	}
+	validator.pending = append(append([]byte(nil), data[complete:count]...), validator.pending...)
+
+	for index := 0; index < complete; {
+		character, size := utf8.DecodeRune(data[index:complete])
+		if character == utf8.RuneError && size <= 1 {
+			if !validator.multibyte {
+				rest := append(append([]byte(nil), data[index:complete]...), validator.pending...)
+				validator.latin1 = transform.NewReader(io.MultiReader(bytes.NewReader(rest), validator.reader), charmap.ISO8859_1.NewDecoder())
+			} else {
+				validator.err = fmt.Errorf("invalid UTF-8 at byte %d", validator.offset+int64(index))
+			}
+
+			validator.offset += int64(index)
+			return index, nil
+		}
+
+		validator.multibyte = validator.multibyte || size > 1
+		index += size
 	}
+	validator.offset += int64(complete)
+
+	return complete, err
+}
 
-	// convert string slice into deque
-	tgContent := processContent(tgData)
-	for _, str := range tgContent {
-		tgDeque.PushBack(str)
+// withPath sets the path of an error if it is a ParseError, so errors point to the path that was given.
+func withPath(err error, path string) error {
+	var parseError *ParseError
+	if errors.As(err, &parseError) {
+		parseError.Path = path
 	}
 
-	// verify the first two entries in the deque
-	err = verifyHead(tgDeque)
+	return err
+}
+
+// parseContent reads the tokens of a TextGrid file into a TextGrid.
+func parseContent(tg *TextGrid, content *lexer) error {
+	// verify the first two tokens of the file
+	err := verifyHead(content, "TextGrid")
 	if err != nil {
-		return tg, fmt.Errorf("error: textgrid %s has malformed header\n %s", tg.name, err.Error())
+		return err
 	}
 
+	return parseBody(tg, content)
+}
+
+// parseBody reads the tokens of a TextGrid following its header, which is also how TextGrids are stored inside a Collection.
+func parseBody(tg *TextGrid, content *lexer) error {
 	// pop the next two values, which should be xmin and xmax respectively.
-	globalXmin, err := pullFloat(tgDeque.PopFront())
+	globalXmin, err := pullFloat(content, "xmin")
 	if err != nil {
-		return tg, fmt.Errorf("error: cannot parse textgrid xmin in %s:\n %s", tg.name, err.Error())
+		return err
 	}
 
-	globalXmax, err := pullFloat(tgDeque.PopFront())
+	globalXmax, err := pullFloat(content, "xmax")
 	if err != nil {
-		return tg, fmt.Errorf("error: cannot parse textgrid xmax in %s:\n %s", tg.name, err.Error())
+		return err
 	}
 
 	// set the xmin and xmax preemptively in case the status is <absent>
@@ -178,31 +331,36 @@ func ReadTextgrid(path string) (TextGrid, error) {
 	tg.xmax = globalXmax
 
 	// the next value is the status, which should either be <absent> or <exists>
-	tierStatus := pullBracketedValue(tgDeque.PopFront())
-	if tierStatus == "absent" {
+	statusToken, err := popToken(content, "tier status")
+	if err != nil {
+		return err
+	}
+
+	tierStatus := statusToken.text
+	if statusToken.kind == flagToken && tierStatus == "absent" {
 		log.Println("warning: tierStatus is <absent>, a textgrid with 0 tiers will be returned")
-		return tg, nil
-	} else if tierStatus != "exists" {
-		return tg, fmt.Errorf("error: expected tier status <exists> or <absent>, recieved <%s>", tierStatus)
+		return nil
+	} else if statusToken.kind != flagToken || tierStatus != "exists" {
+		return newParseError(statusToken, "tier status", fmt.Errorf("expected <exists> or <absent>, recieved <%s>", tierStatus))
 	}
 
 	// get the number of tiers that exist in this textgrid
-	numTiers, err := pullInt(tgDeque.PopFront())
+	numTiers, err := pullInt(content, "tier count")
 	if err != nil {
-		return tg, fmt.Errorf("error: cannot parse textgrid numTiers:\n %s", err.Error())
+		return err
 	}
 
-	tiers, err := parseTiers(globalXmin, globalXmax, tgDeque, numTiers)
+	tiers, err := parseTiers(globalXmin, globalXmax, content, numTiers)
 	if err != nil {
-		return tg, fmt.Errorf("error: cannot parse textgrid tiers:\n %s", err.Error())
+		return err
 	}
 	tg.tiers = tiers
 
-	return tg, nil
+	return nil
 }
 
 // WriteLong writes to a .TextGrid file in long format.
-// Will overwrite existing files unless otherwise specified.
+// Existing files are only overwritten if overwrite is set to true.
 // If the path is a directory, the contents will be written to a file with the same name as the TextGrid, in the directory.
 func (tg *TextGrid) WriteLong(path string, overwrite ...bool) error {
 	// default to false
@@ -210,59 +368,30 @@ func (tg *TextGrid) WriteLong(path string, overwrite ...bool) error {
 		overwrite = append(overwrite, false)
 	}
 
-	// replace backslashes with forward slashes
-	path = strings.Replace(path, "\\", "/", -1)
-
-	var fileName string
-	pathInfo, err := os.Stat(path)
-	if err != nil {
-		if filepath.Ext(path) != "" {
-			// if the path is a file, make the directory it is in
-			pathSplit := strings.Split(path, "/")
-			err := os.MkdirAll(strings.Join(pathSplit[0:len(pathSplit)-1], "/"), os.ModePerm)
-			if err != nil {
-				return err
-			}
-		} else {
-			// if the path is a directory, make it
-			err := os.MkdirAll(path, os.ModePerm)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	// if path is a directory, construct the desired filename. If path is a file, make it.
-	if filepath.Ext(path) == "" || (pathInfo != nil && pathInfo.IsDir()) {
-		fileName = filepath.Join(path, tg.name+".TextGrid")
-	} else {
-		// if the path is a file, check if it already exists and if overwrite is false
-		if pathInfo != nil && !overwrite[0] {
-			return fmt.Errorf("error writing textgrid %q: file %s already exists", tg.name, path)
-		}
-		fileName = path
-	}
+	return tg.WriteLongWith(path, fileio.WithOverwrite(overwrite[0]))
+}
 
-	// create the file with the filename defined above
-	file, err := os.Create(fileName)
-	if err != nil {
-		return err
-	}
-	defer func(file *os.File) {
-		closingError := file.Close()
-		if err == nil {
-			err = closingError
-		}
-	}(file)
+// WriteLongWith writes to a .TextGrid file in long format like WriteLong, following the given write options.
+// By default, existing files are not overwritten, missing directories are created and the file is written atomically.
+func (tg *TextGrid) WriteLongWith(path string, options ...fileio.WriteOption) error {
+	return fileio.WriteFile(fileio.ResolvePath(path, tg.name+".TextGrid"), tg.writeLong, options...)
+}
 
+// writeLong writes a TextGrid to an io.Writer in long format.
+func (tg *TextGrid) writeLong(writer io.Writer) error {
 	// create the header of the textgrid file
-	_, err = fmt.Fprintf(file, "File type = \"ooTextFile\"\nObject class = \"TextGrid\"\n\n")
+	_, err := fmt.Fprintf(writer, "File type = \"ooTextFile\"\nObject class = \"TextGrid\"\n\n")
 	if err != nil {
 		return err
 	}
 
+	return tg.writeLongBody(writer)
+}
+
+// writeLongBody writes everything following the header of a TextGrid in long format.
+func (tg *TextGrid) writeLongBody(writer io.Writer) error {
 	// create the xmin and xmax of the textgrid file
-	_, err = fmt.Fprintf(file, "xmin = %s\nxmax = %s\n", f2s(tg.xmin), f2s(tg.xmax))
+	_, err := fmt.Fprintf(writer, "xmin = %s\nxmax = %s\n", f2s(tg.xmin), f2s(tg.xmax))
 	if err != nil {
 		return err
 	}
@@ -270,95 +399,95 @@ func (tg *TextGrid) WriteLong(path string, overwrite ...bool) error {
 	// create the tier flag
 	// is usually <exists> if you have tiers, but can also be <absent> if you somehow have a tier-less textgrid
 	if tg.tiers == nil {
-		_, err = fmt.Fprintf(file, "tiers? <absent>")
+		_, err = fmt.Fprintf(writer, "tiers? <absent>")
 		return nil
 	} else {
-		_, err = fmt.Fprintf(file, "tiers? <exists>")
+		_, err = fmt.Fprintf(writer, "tiers? <exists>")
 	}
 
 	// create the size field, which is the number of tiers in the textgrid.
-	_, err = fmt.Fprintf(file, "\nsize = %d\n", tg.GetSize())
+	_, err = fmt.Fprintf(writer, "\nsize = %d\n", tg.GetSize())
 	if err != nil {
 		return err
 	}
 
 	// begin writing tiers, which starts with the blank item [] field
-	_, err = fmt.Fprintf(file, "item []:\n")
+	_, err = fmt.Fprintf(writer, "item []:\n")
 	if err != nil {
 		return err
 	}
 
 	for tierNum, tier := range tg.tiers {
 		// write tier info
-		_, err = fmt.Fprintf(file, "\titem [%d]:\n", tierNum+1)
+		_, err = fmt.Fprintf(writer, "\titem [%d]:\n", tierNum+1)
 		if err != nil {
 			return err
 		}
 
 		// tier class
-		_, err = fmt.Fprintf(file, "\t\tclass = %q\n", tier.GetType())
+		_, err = fmt.Fprintf(writer, "\t\tclass = %s\n", quote(tier.GetType()))
 		if err != nil {
 			return err
 		}
 
 		// tier name
-		_, err = fmt.Fprintf(file, "\t\tname = %q\n", tier.GetName())
+		_, err = fmt.Fprintf(writer, "\t\tname = %s\n", quote(tier.GetName()))
 		if err != nil {
 			return err
 		}
 
 		// xmin and xmax
-		_, err = fmt.Fprintf(file, "\t\txmin = %s\n\t\txmax = %s\n", f2s(tier.GetXmin()), f2s(tier.GetXmax()))
+		_, err = fmt.Fprintf(writer, "\t\txmin = %s\n\t\txmax = %s\n", f2s(tier.GetXmin()), f2s(tier.GetXmax()))
 
 		// write content info and contents of tier
 		if tier.GetType() == "IntervalTier" {
 			// if tier is interval tier
-			_, err = fmt.Fprintf(file, "\t\tintervals: size = %d\n", tier.GetSize())
+			_, err = fmt.Fprintf(writer, "\t\tintervals: size = %d\n", tier.GetSize())
 			if err != nil {
 				return err
 			}
 
 			for intervalNum, interval := range tier.GetIntervals() {
 				// write interval number
-				_, err = fmt.Fprintf(file, "\t\tintervals [%d]:\n", intervalNum+1)
+				_, err = fmt.Fprintf(writer, "\t\tintervals [%d]:\n", intervalNum+1)
 				if err != nil {
 					return err
 				}
 
 				// xmin and xmax
-				_, err = fmt.Fprintf(file, "\t\t\txmin = %s\n\t\t\txmax = %s\n", f2s(interval.xmin), f2s(interval.xmax))
+				_, err = fmt.Fprintf(writer, "\t\t\txmin = %s\n\t\t\txmax = %s\n", f2s(interval.xmin), f2s(interval.xmax))
 				if err != nil {
 					return err
 				}
 
 				// text
-				_, err = fmt.Fprintf(file, "\t\t\ttext = %q\n", interval.text)
+				_, err = fmt.Fprintf(writer, "\t\t\ttext = %s\n", quote(interval.text))
 				if err != nil {
 					return err
 				}
 			}
 		} else {
 			// if tier is point tier
-			_, err = fmt.Fprintf(file, "\t\tpoints: size = %d\n", tier.GetSize())
+			_, err = fmt.Fprintf(writer, "\t\tpoints: size = %d\n", tier.GetSize())
 			if err != nil {
 				return err
 			}
 
 			for pointNum, point := range tier.GetPoints() {
 				// write point number
-				_, err = fmt.Fprintf(file, "\t\tpoints [%d]:\n", pointNum)
+				_, err = fmt.Fprintf(writer, "\t\tpoints [%d]:\n", pointNum)
 				if err != nil {
 					return err
 				}
 
 				// value
-				_, err = fmt.Fprintf(file, "\t\t\tnumber = %s\n", f2s(point.value))
+				_, err = fmt.Fprintf(writer, "\t\t\tnumber = %s\n", f2s(point.value))
 				if err != nil {
 					return err
 				}
 
 				// mark
-				_, err = fmt.Fprintf(file, "\t\t\tmark = %q\n", point.mark)
+				_, err = fmt.Fprintf(writer, "\t\t\tmark = %s\n", quote(point.mark))
 			}
 		}
 	}
@@ -367,7 +496,7 @@ func (tg *TextGrid) WriteLong(path string, overwrite ...bool) error {
 }
 
 // WriteShort writes to a .TextGrid file in short format.
-// Will overwrite existing files unless otherwise specified.
+// Existing files are only overwritten if overwrite is set to true.
 // If the path is a directory, the contents will be written to a file with the same name as the TextGrid, in the directory.
 func (tg *TextGrid) WriteShort(path string, overwrite ...bool) error {
 	// default to false
@@ -375,59 +504,25 @@ func (tg *TextGrid) WriteShort(path string, overwrite ...bool) error {
 		overwrite = append(overwrite, false)
 	}
 
-	// replace backslashes with forward slashes
-	path = strings.Replace(path, "\\", "/", -1)
-
-	var fileName string
-	pathInfo, err := os.Stat(path)
-	if err != nil {
-		if filepath.Ext(path) != "" {
-			// if the path is a file, make the directory it is in
-			pathSplit := strings.Split(path, "/")
-			err := os.MkdirAll(strings.Join(pathSplit[0:len(pathSplit)-1], "/"), os.ModePerm)
-			if err != nil {
-				return err
-			}
-		} else {
-			// if the path is a directory, make it
-			err := os.MkdirAll(path, os.ModePerm)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	// if path is a directory, construct the desired filename. If path is a file, make it.
-	if filepath.Ext(path) == "" || (pathInfo != nil && pathInfo.IsDir()) {
-		fileName = filepath.Join(path, tg.name+".TextGrid")
-	} else {
-		// if the path is a file, check if it already exists and if overwrite is false
-		if pathInfo != nil && !overwrite[0] {
-			return fmt.Errorf("error writing textgrid %q: file %s already exists", tg.name, path)
-		}
-		fileName = path
-	}
+	return tg.WriteShortWith(path, fileio.WithOverwrite(overwrite[0]))
+}
 
-	// create the file with the filename defined above
-	file, err := os.Create(fileName)
-	if err != nil {
-		return err
-	}
-	defer func(file *os.File) {
-		closingError := file.Close()
-		if err == nil {
-			err = closingError
-		}
-	}(file)
+// WriteShortWith writes to a .TextGrid file in short format like WriteShort, following the given write options.
+// By default, existing files are not overwritten, missing directories are created and the file is written atomically.
+func (tg *TextGrid) WriteShortWith(path string, options ...fileio.WriteOption) error {
+	return fileio.WriteFile(fileio.ResolvePath(path, tg.name+".TextGrid"), tg.writeShort, options...)
+}
 
+// writeShort writes a TextGrid to an io.Writer in short format.
+func (tg *TextGrid) writeShort(writer io.Writer) error {
 	// create the header of the textgrid file
-	_, err = fmt.Fprintf(file, "File type = \"ooTextFile\"\nObject class = \"TextGrid\"\n\n")
+	_, err := fmt.Fprintf(writer, "File type = \"ooTextFile\"\nObject class = \"TextGrid\"\n\n")
 	if err != nil {
 		return err
 	}
 
 	// create the xmin and xmax of the textgrid file
-	_, err = fmt.Fprintf(file, "%s\n%s\n", f2s(tg.xmin), f2s(tg.xmax))
+	_, err = fmt.Fprintf(writer, "%s\n%s\n", f2s(tg.xmin), f2s(tg.xmax))
 	if err != nil {
 		return err
 	}
@@ -435,71 +530,71 @@ func (tg *TextGrid) WriteShort(path string, overwrite ...bool) error {
 	// create the tier flag
 	// is usually <exists> if you have tiers, but can also be <absent> if you somehow have a tier-less textgrid
 	if tg.tiers == nil {
-		_, err = fmt.Fprintf(file, "<absent>")
+		_, err = fmt.Fprintf(writer, "<absent>")
 		return nil
 	} else {
-		_, err = fmt.Fprintf(file, "<exists>")
+		_, err = fmt.Fprintf(writer, "<exists>")
 	}
 
 	// create the size field, which is the number of tiers in the textgrid.
-	_, err = fmt.Fprintf(file, "\n%d\n", tg.GetSize())
+	_, err = fmt.Fprintf(writer, "\n%d\n", tg.GetSize())
 	if err != nil {
 		return err
 	}
 
 	for _, tier := range tg.tiers {
 		// tier class
-		_, err = fmt.Fprintf(file, "%q\n", tier.GetType())
+		_, err = fmt.Fprintf(writer, "%s\n", quote(tier.GetType()))
 		if err != nil {
 			return err
 		}
 
 		// tier name
-		_, err = fmt.Fprintf(file, "%q\n", tier.GetName())
+		_, err = fmt.Fprintf(writer, "%s\n", quote(tier.GetName()))
 		if err != nil {
 			return err
 		}
 
 		// xmin and xmax
-		_, err = fmt.Fprintf(file, "%s\n%s\n", f2s(tier.GetXmin()), f2s(tier.GetXmax()))
+		_, err = fmt.Fprintf(writer, "%s\n%s\n", f2s(tier.GetXmin()), f2s(tier.GetXmax()))
 
 		// write content info and contents of tier
 		if tier.GetType() == "IntervalTier" {
 			// if tier is interval tier
-			_, err = fmt.Fprintf(file, "%d\n", tier.GetSize())
+			_, err = fmt.Fprintf(writer, "%d\n", tier.GetSize())
 			if err != nil {
 				return err
 			}
 
 			for _, interval := range tier.GetIntervals() {
 				// xmin and xmax
-				_, err = fmt.Fprintf(file, "%s\n%s\n", f2s(interval.xmin), f2s(interval.xmax))
+				_, err = fmt.Fprintf(writer, "%s\n%s\n", f2s(interval.xmin), f2s(interval.xmax))
 				if err != nil {
 					return err
 				}
 
 				// text
-				_, err = fmt.Fprintf(file, "%q\n", interval.text)
+				_, err = fmt.Fprintf(writer, "%s\n", quote(interval.text))
 				if err != nil {
 					return err
 				}
 			}
 		} else {
 			// if tier is point tier
-			_, err = fmt.Fprintf(file, "%d\n", tier.GetSize())
+			_, err = fmt.Fprintf(writer, "%d\n", tier.GetSize())
 			if err != nil {
 				return err
 			}
 
 			for _, point := range tier.GetPoints() {
 				// value
-				_, err = fmt.Fprintf(file, "%s\n", f2s(point.value))
+				_, err = fmt.Fprintf(writer, "%s\n", f2s(point.value))
 				if err != nil {
 					return err
 				}
 
 				// mark
-				_, err = fmt.Fprintf(file, "%q\n", point.mark)
+				_, err = fmt.Fprintf(writer, "%s\n", quote(point.mark))
 			}
 		}
 	}
@@ -507,37 +602,47 @@ func (tg *TextGrid) WriteShort(path string, overwrite ...bool) error {
 	return nil
 }
 
-// parseTiers converts headless TextGrid deque into Tier slice.
-func parseTiers(globalXmin float64, globalXmax float64, content *deque.Deque[string], numTiers int) ([]Tier, error) {
+// parseTiers reads the tiers of a TextGrid from its tokens into a Tier slice.
+func parseTiers(globalXmin float64, globalXmax float64, content *lexer, numTiers int) ([]Tier, error) {
 	var tiers []Tier
 	tierCounter := 0
 
 	for tierCounter < numTiers {
+		tierField := fmt.Sprintf("tier %d", tierCounter+1)
+
 		// at the start of a tier, the first two values will be tierType and tierName
-		tierType := pullQuotedValue(content.PopFront())
-		tierName := pullQuotedValue(content.PopFront())
+		typeToken, err := pullString(content, "class of "+tierField)
+		if err != nil {
+			return nil, err
+		}
+		nameToken, err := pullString(content, "name of "+tierField)
+		if err != nil {
+			return nil, err
+		}
+		tierType := typeToken.text
+		tierName := nameToken.text
 
 		// the next two values should be the xmin and xmax of the unique tier
-		tierXmin, err := pullFloat(content.PopFront())
+		tierXmin, err := pullFloat(content, "xmin of "+tierField)
 		if err != nil {
-			return nil, fmt.Errorf("error: cannot parse tier %d xmin: %v", tierCounter+1, err)
+			return nil, err
 		}
 
-		tierXmax, err := pullFloat(content.PopFront())
+		tierXmax, err := pullFloat(content, "xmax of "+tierField)
 		if err != nil {
-			return nil, fmt.Errorf("error: cannot parse tier %d xmax: %v", tierCounter+1, err)
+			return nil, err
 		}
 
 		// check to see if any boundaries are inconsistent
 		if tierXmin < globalXmin {
-			return nil, fmt.Errorf("error: %s %s has xmin %f, when TextGrid xmin is %f", tierType, tierName, tierXmin, globalXmin)
+			return nil, newParseError(typeToken, "xmin of "+tierField, fmt.Errorf("%s %s has xmin %f, when TextGrid xmin is %f", tierType, tierName, tierXmin, globalXmin))
 		}
 		if tierXmax > globalXmax {
-			return nil, fmt.Errorf("error: %s %s has xmax %f, when TextGrid xmax is %f", tierType, tierName, tierXmax, globalXmax)
+			return nil, newParseError(typeToken, "xmax of "+tierField, fmt.Errorf("%s %s has xmax %f, when TextGrid xmax is %f", tierType, tierName, tierXmax, globalXmax))
 		}
 
 		// the last value before the intervals/points begin should be the number of intervals/points in the unique tier
-		tierContentCount, err := pullInt(content.PopFront())
+		tierContentCount, err := pullInt(content, "size of "+tierField)
 		if err != nil {
 			return nil, err
 		}
@@ -549,18 +654,24 @@ func parseTiers(globalXmin float64, globalXmax float64, content *deque.Deque[str
 			var intervals []Interval
 
 			for contentCounter != tierContentCount {
+				intervalField := fmt.Sprintf("interval %d in %s", contentCounter+1, tierField)
+
 				// the next three values in an interval are the xmin, xmax, and text
-				intervalXmin, err := pullFloat(content.PopFront())
+				intervalXmin, err := pullFloat(content, "xmin of "+intervalField)
 				if err != nil {
-					return nil, fmt.Errorf("error: cannot parse xmin in tier %d, interval %d; %v", tierCounter+1, contentCounter+1, err)
+					return nil, err
 				}
 
-				intervalXmax, err := pullFloat(content.PopFront())
+				intervalXmax, err := pullFloat(content, "xmax of "+intervalField)
 				if err != nil {
-					return nil, fmt.Errorf("error: cannot parse xmax in tier %d, interval %d; %v", tierCounter+1, contentCounter+1, err)
+					return nil, err
 				}
 
-				intervalText := pullQuotedValue(content.PopFront())
+				textToken, err := pullString(content, "text of "+intervalField)
+				if err != nil {
+					return nil, err
+				}
+				intervalText := textToken.text
 
 				// create the new interval
 				newInterval := Interval{xmin: intervalXmin, xmax: intervalXmax, text: intervalText}
@@ -577,13 +688,19 @@ func parseTiers(globalXmin float64, globalXmax float64, content *deque.Deque[str
 			var points []Point
 
 			for contentCounter != tierContentCount {
+				pointField := fmt.Sprintf("point %d in %s", contentCounter+1, tierField)
+
 				// the next two values in a point are the value and the mark
-				pointValue, err := pullFloat(content.PopFront())
+				pointValue, err := pullFloat(content, "number of "+pointField)
 				if err != nil {
-					return nil, fmt.Errorf("error: cannot parse value in tier %d, point %d; %v", tierCounter+1, contentCounter+1, err)
+					return nil, err
 				}
 
-				pointMark := pullQuotedValue(content.PopFront())
+				markToken, err := pullString(content, "mark of "+pointField)
+				if err != nil {
+					return nil, err
+				}
+				pointMark := markToken.text
 
 				// create the new point
 				newPoint := Point{value: pointValue, mark: pointMark}
@@ -596,7 +713,7 @@ func parseTiers(globalXmin float64, globalXmax float64, content *deque.Deque[str
 			newPointTier := PointTier{name: tierName, xmin: tierXmin, xmax: tierXmax, points: points}
 			tiers = append(tiers, &newPointTier)
 		} else {
-			return nil, fmt.Errorf("error: unexpected tier type %s", tierType)
+			return nil, newParseError(typeToken, "class of "+tierField, fmt.Errorf("unexpected tier type %s", tierType))
 		}
 		tierCounter++
 	}
@@ -604,90 +721,118 @@ func parseTiers(globalXmin float64, globalXmax float64, content *deque.Deque[str
 	return tiers, nil
 }
 
-// processContent turns textgrid file content into a slice of usable strings.
-// internally, any textgrid given is converted into a "short" type textgrid with any empty lines removed
-func processContent(data []byte) []string {
+// verifyHead checks the necessary FileType and ObjectClass fields of a TextGrid or Collection.
+func verifyHead(tgContent *lexer, class string) error {
+	fileType, err := pullString(tgContent, "file type")
+	if err != nil {
+		return err
+	}
+	objectClass, err := pullString(tgContent, "object class")
+	if err != nil {
+		return err
+	}
 
-	// remove all empty lines
-	tgString := strings.ReplaceAll(string(data), "\n\n", "\n")
+	// older versions of Praat mark short files in the file type
+	if fileType.text != "ooTextFile" && fileType.text != "ooTextFile short" {
+		return newParseError(fileType, "file type", fmt.Errorf("wanted fileType ooTextFile, recieved %s", fileType.text))
+	}
 
-	bracketRegex := regexp.MustCompile(`\[\d+]`)
+	if objectClass.text != class {
+		return newParseError(objectClass, "object class", fmt.Errorf("wanted objectClass %s, recieved %s", class, objectClass.text))
+	}
 
-	// a short textgrid is basically a textgrid that is only labels, numbers, and flags.
-	// we will use regex to remove everything that isn't needed by praat to recognize a textgrid.
-	// `\d+(\.\d+)?` matches all floats and integers
-	// `\"[^\"]*\` matches all content in between double quotes
-	// `\<[^>]*>` matches all content in between angle brackets
-	textgridRegex := regexp.MustCompile(`(\d+(\.\d+)?|"[^"]*"|<[^>]*)`)
+	return nil
+}
 
-	tgSanitized := strings.Join(textgridRegex.FindAllString(bracketRegex.ReplaceAllString(tgString, ""), -1), "\n")
+// popToken reads the next token of the file. Returns a ParseError if the file ends before the field.
+func popToken(content *lexer, field string) (token, error) {
+	tok, err := content.next()
+	switch {
+	case err == io.EOF:
+		return tok, &ParseError{Field: field, Cause: io.ErrUnexpectedEOF}
+	case err != nil && tok.line > 0:
+		return tok, newParseError(tok, field, err)
+	case err != nil:
+		// errors reading the file, such as invalid UTF-8, point to where the lexer stopped
+		return tok, &ParseError{Line: content.line, Column: content.column + 1, Field: field, Cause: err}
+	}
 
-	return strings.Split(tgSanitized, "\n")
+	return tok, err
 }
 
-// verifyHead checks the necessary FileType and ObjectClass fields of a TextGrid.
-func verifyHead(tgContent *deque.Deque[string]) error {
-	fileType := tgContent.PopFront()
-	objectClass := tgContent.PopFront()
-
-	if pullQuotedValue(fileType) != "ooTextFile" {
-		return fmt.Errorf("error: wanted fileType ooTextFile, recieved %s", fileType)
+// pullString reads the next token, which has to be a string.
+func pullString(content *lexer, field string) (token, error) {
+	tok, err := popToken(content, field)
+	if err != nil {
+		return tok, err
 	}
 
-	if pullQuotedValue(objectClass) != "TextGrid" {
-		return fmt.Errorf("error: wanted objectClass TextGrid, recieved %s", objectClass)
+	if tok.kind != stringToken {
+		return tok, newParseError(tok, field, fmt.Errorf("wanted a string in double quotes, recieved %s", tok.text))
 	}
 
-	return nil
+	return tok, nil
 }
 
-// pullQuotedValue takes a value contained in quotes and returns it without quotes.
-// quotes inside quotes will be preserved.
-func pullQuotedValue(str string) string {
-	stringRegex := regexp.MustCompile(`^"(.*)"$`)
-	return stringRegex.ReplaceAllString(str, `$1`)
-}
+// pullNumber reads the next token, which has to be a number.
+// Other tokens return a ParseError wrapping strconv.ErrSyntax, like a number that cannot be converted.
+func pullNumber(content *lexer, field string, function string) (token, error) {
+	tok, err := popToken(content, field)
+	if err != nil {
+		return tok, err
+	}
 
-// pullBracketedValue takes a value that has angle brackets and removes them.
-// this is only used for flags in TextGrids, so internal brackets are ignored.
-func pullBracketedValue(str string) string {
-	stringRegex := regexp.MustCompile(`[<>]`)
-	return stringRegex.ReplaceAllString(str, "")
+	if tok.kind != numberToken {
+		return tok, newParseError(tok, field, &strconv.NumError{Func: function, Num: tok.text, Err: strconv.ErrSyntax})
+	}
+
+	return tok, nil
 }
 
-// pullInt converts string to int.
-func pullInt(str string) (int, error) {
-	result, err := strconv.Atoi(str)
+// pullInt converts the next token into an int.
+func pullInt(content *lexer, field string) (int, error) {
+	tok, err := pullNumber(content, field, "Atoi")
 	if err != nil {
-		return result, err
+		return 0, err
 	}
 
-	return result, nil
-}
-
-// pullFloat converts string to float64.
-func pullFloat(str string) (float64, error) {
-	result, err := strconv.ParseFloat(str, 64)
+	result, err := strconv.Atoi(tok.text)
 	if err != nil {
-		return result, err
+		return result, newParseError(tok, field, err)
 	}
 
 	return result, nil
 }
 
-// getEncoding returns encoding type of byte slice.
-func getEncoding(data []byte) (string, error) {
-	detector := chardet.NewTextDetector()
+// pullFloat converts the next token into a float64. Numbers Praat wrote as undefined are NaN.
+func pullFloat(content *lexer, field string) (float64, error) {
+	tok, err := pullNumber(content, field, "ParseFloat")
+	if err != nil {
+		return 0, err
+	}
 
-	detectedEncoding, err := detector.DetectBest(data)
+	if tok.text == undefinedNumber {
+		return math.NaN(), nil
+	}
+
+	result, err := strconv.ParseFloat(tok.text, 64)
 	if err != nil {
-		return "", err
+		return result, newParseError(tok, field, err)
 	}
 
-	return detectedEncoding.Charset, nil
+	return result, nil
 }
 
-// f2s converts float into string with preservation.
+// quote puts a string in between double quotes, doubling any quotes inside it like Praat does.
+func quote(text string) string {
+	return "\"" + strings.ReplaceAll(text, "\"", "\"\"") + "\""
+}
+
+// f2s converts float into string with preservation. Undefined numbers are written the way Praat writes them.
 func f2s(f float64) string {
+	if math.IsNaN(f) {
+		return undefinedNumber
+	}
+
 	return strconv.FormatFloat(f, 'f', -1, 64)
 }
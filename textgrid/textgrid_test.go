@@ -1,6 +1,18 @@
 package textgrid
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vocatart/golab/fileio"
+)
 
 func TestCreatingTextGrid(t *testing.T) {
 	tg := TextGrid{
@@ -139,6 +151,37 @@ func TestReadingTextgridUTF8(t *testing.T) {
 	}
 }
 
+func TestReadingTextgridLatin1(t *testing.T) {
+	// saved with the "try ISO Latin-1" text encoding preference of Praat
+	tg, err := ReadTextgrid("examples/latin1.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []string
+	for _, interval := range tg.GetTier("mots").GetIntervals() {
+		texts = append(texts, interval.GetText())
+	}
+	if !reflect.DeepEqual(texts, []string{"café", "été", "naïve"}) {
+		t.Errorf("expected texts [café été naïve], got %v", texts)
+	}
+}
+
+func TestReadingTextgridUTF8ByteOrderMark(t *testing.T) {
+	data, err := os.ReadFile("examples/long.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tg, err := ParseTextgrid(bytes.NewReader(append([]byte("\xEF\xBB\xBF"), data...)), "bom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tg.GetTiers()) != 3 {
+		t.Errorf("expected 3 tiers, got %d", len(tg.GetTiers()))
+	}
+}
+
 func TestWritingLongTextgrid(t *testing.T) {
 	tg, err := ReadTextgrid("examples/long.TextGrid")
 	if err != nil {
@@ -162,3 +205,121 @@ func TestWritingShortTextgrid(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestWritingTextgridDryRun(t *testing.T) {
+	tg, err := ReadTextgrid("examples/long.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tg.WriteLongWith("examples/dry_run/long.TextGrid", fileio.WithDryRun(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat("examples/dry_run"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected nothing to be written, got %v", err)
+	}
+
+	err = tg.WriteShortWith("examples/short_output.TextGrid")
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected error for existing file, got %v", err)
+	}
+}
+
+func TestReadingConformingTextgrid(t *testing.T) {
+	tg, err := ReadTextgrid("examples/conformance.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tg.GetXmin() != -0.5 || tg.GetXmax() != 1.5 {
+		t.Errorf("expected xmin -0.5 and xmax 1.5, got %f and %f", tg.GetXmin(), tg.GetXmax())
+	}
+
+	expected := []Tier{
+		&IntervalTier{name: "words \"quoted\"", xmin: -0.5, xmax: 1.5, intervals: []Interval{
+			{xmin: -0.5, xmax: 1e-05, text: ""},
+			{xmin: 1e-05, xmax: 0.75, text: "she said \"hi\""},
+			{xmin: 0.75, xmax: 1.5, text: "line one\nline two"},
+		}},
+		&PointTier{name: "marks", xmin: -0.5, xmax: 1.5, points: []Point{
+			{value: 0.25, mark: "!not a comment"},
+		}},
+	}
+	if !reflect.DeepEqual(tg.GetTiers(), expected) {
+		t.Errorf("expected tiers %v, got %v", expected, tg.GetTiers())
+	}
+}
+
+func TestReadingMinimalTextgrid(t *testing.T) {
+	tg, err := ReadTextgrid("examples/minimal.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tg.GetSize() != 2 || tg.GetTier("Bell").GetSize() != 2 {
+		t.Fatalf("expected 2 tiers with 2 points in \"Bell\", got %d tiers", tg.GetSize())
+	}
+	if tg.GetTier("Bell").GetPoints()[1].GetMark() != "dong" {
+		t.Errorf("expected mark \"dong\", got %q", tg.GetTier("Bell").GetPoints()[1].GetMark())
+	}
+}
+
+func TestConformingRoundTrip(t *testing.T) {
+	tg, err := ReadTextgrid("examples/conformance.TextGrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, write := range []func(io.Writer) error{tg.writeLong, tg.writeShort, tg.writeBinary} {
+		var buffer bytes.Buffer
+		err = write(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := ParseTextgrid(&buffer, "conformance")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(result.GetTiers(), tg.GetTiers()) {
+			t.Errorf("expected tiers %v, got %v", tg.GetTiers(), result.GetTiers())
+		}
+	}
+}
+
+func TestReadingUndefinedNumbers(t *testing.T) {
+	content := "\"ooTextFile\"\n\"TextGrid\"\n0 1 <exists> 1\n\"TextTier\" \"marks\" 0 1 1\n--undefined-- \"unknown\"\n"
+
+	tg, err := ParseTextgrid(strings.NewReader(content), "undefined")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := tg.GetTier("marks").GetPoints()[0].GetValue()
+	if !math.IsNaN(value) {
+		t.Fatalf("expected NaN for an undefined number, got %f", value)
+	}
+
+	var buffer bytes.Buffer
+	err = tg.writeShort(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "--undefined--") {
+		t.Errorf("expected undefined numbers to be written as --undefined--, got %q", buffer.String())
+	}
+}
+
+func TestReadingStringsAsNumbers(t *testing.T) {
+	content := "\"ooTextFile\"\n\"TextGrid\"\n0 \"1\" <absent>\n"
+
+	_, err := ParseTextgrid(strings.NewReader(content), "strings")
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) || parseError.Field != "xmax" || parseError.Line != 3 || parseError.Column != 3 {
+		t.Fatalf("expected ParseError for xmax at 3:3, got %v", err)
+	}
+}